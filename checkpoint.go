@@ -0,0 +1,493 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	checkpointExtension = ".ckp"
+	checkpointVersion   = "4"
+	ckpHeader           = "CKP"
+	ckpData             = "D"
+	ckpPeriod           = "P"
+	ckpEntry            = "E"
+	ckpState            = "S"
+	ckpChecksum         = "CRC"
+	ckpHeaderFields     = 4
+	ckpDataFields       = 4
+	ckpPeriodFields     = 4
+	ckpEntryFields      = 4
+	ckpStateFields      = 9
+)
+
+// checkpoint holds the state of a store restored from a checkpoint file.
+type checkpoint struct {
+	fName   string
+	offset  int64
+	data    map[string]*dataPoint
+	periods map[string]time.Duration
+	samples map[string]uint64
+	entries []checkpointEntry
+	states  map[[2]string]checkpointState
+}
+
+// checkpointState is the state of a window not derived from its entries
+// keyed by data and window key.
+type checkpointState struct {
+	lastTS time.Time
+	count  uint64
+	avg    float64
+	total  float64
+	min    float64
+	max    float64
+}
+
+// checkpointEntry is a single window entry restored from a checkpoint file.
+type checkpointEntry struct {
+	datKey    string
+	timestamp time.Time
+	value     float64
+}
+
+// SetCheckpointInterval enables checkpoints written at most once per
+// interval (measured by the store's clock) as values are updated and again
+// when the store is closed.  A checkpoint records the latest value of each
+// key along with the contents of its windows permitting Open to replay only
+// the records written after it.  A zero (the default) disables checkpoints.
+func (fs *fileStore) SetCheckpointInterval(interval time.Duration) {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	fs.checkpointInterval = max(interval, 0)
+}
+
+// Checkpoint immediately writes a checkpoint of the opened store.
+func (fs *fileStore) Checkpoint() error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if !fs.opened {
+		return ErrNotOpened
+	}
+
+	return fs.writeCheckpoint()
+}
+
+func (fs *fileStore) checkpointPath() string {
+	return fs.filePath(fs.filenameRoot + checkpointExtension)
+}
+
+// checkpointIfDue writes a checkpoint if the interval has elapsed since the
+// last one was written.
+func (fs *fileStore) checkpointIfDue(timestamp time.Time) {
+	if fs.checkpointInterval == 0 ||
+		timestamp.Sub(fs.lastCheckpoint) < fs.checkpointInterval {
+		return
+	}
+
+	fs.lastCheckpoint = timestamp
+
+	err := fs.writeCheckpoint()
+	if err != nil {
+		log.Print("checkpoint failed: " + err.Error())
+	}
+}
+
+// writeCheckpoint atomically replaces the checkpoint file with the current
+// state of the store.
+func (fs *fileStore) writeCheckpoint() error {
+	if fs.currentFile == nil {
+		return nil
+	}
+
+	var body strings.Builder
+
+	fmt.Fprintf(&body, "%s|%s|%s|%d\n",
+		ckpHeader, checkpointVersion,
+		filepath.Base(fs.currentFile.Name()), fs.currentFileSize,
+	)
+
+	for _, datKey := range slices.Sorted(maps.Keys(fs.data)) {
+		d := fs.data[datKey]
+		fmt.Fprintf(&body, "%s|%s|%s|%s\n",
+			ckpData, d.TS.Format(fmtTimeStamp), datKey, encodeValue(d.Value),
+		)
+
+		wdb, ok := fs.winDB[datKey]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(&body, "%s|%s|%d|%d\n",
+			ckpPeriod, datKey, int64(wdb.maxPeriod), wdb.maxSamples,
+		)
+
+		for e := wdb.oldestEntry; e != nil; e = e.prev {
+			fmt.Fprintf(&body, "%s|%s|%s|%s\n",
+				ckpEntry, datKey, e.timestamp.Format(fmtTimeStamp),
+				formatFloat(e.value),
+			)
+		}
+
+		for _, winKey := range wdb.winKeys {
+			w := wdb.windows[winKey]
+			if w.stateful() {
+				fmt.Fprintf(&body, "%s|%s|%s|%s|%d|%s|%s|%s|%s\n",
+					ckpState, datKey, winKey, w.lastTS.Format(fmtTimeStamp),
+					w.count, formatFloat(w.avg), formatFloat(w.total),
+					formatFloat(w.bucketMin), formatFloat(w.bucketMax),
+				)
+			}
+		}
+	}
+
+	fmt.Fprintf(&body, "%s|%08x\n",
+		ckpChecksum, crc32.ChecksumIEEE([]byte(body.String())),
+	)
+
+	return writeAtomic(fs.checkpointPath(), body.String())
+}
+
+// writeAtomic writes the data to a temporary file renaming it into place
+// once it has been synced.
+func writeAtomic(fPath, data string) error {
+	tmpPath := fPath + temporaryExtension
+
+	f, err := os.OpenFile( //nolint:gosec // Ok.
+		tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, defaultFilePermissions,
+	)
+	if err != nil {
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	_, err = f.WriteString(data)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, fPath)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+
+	return err //nolint:wrapcheck // Ok.
+}
+
+// restoreCheckpoint loads the checkpoint (if any) returning the index of the
+// data file and the offset within it from where history must be replayed.
+// A missing or invalid checkpoint replays the entire history.
+func (fs *fileStore) restoreCheckpoint() (int, int64) {
+	ckp, err := readCheckpoint(fs.checkpointPath())
+
+	idx := 0
+	if err == nil && ckp != nil {
+		idx, err = fs.validateCheckpoint(ckp)
+	}
+
+	if err != nil {
+		log.Print("checkpoint ignored: " + err.Error())
+
+		return 0, 0
+	}
+
+	if ckp == nil {
+		return 0, 0
+	}
+
+	for datKey, d := range ckp.data {
+		fs.data[datKey] = d
+
+		if _, ok := fs.winDB[datKey]; !ok {
+			fs.winDB[datKey] = newWinDB(datKey)
+		}
+	}
+
+	for _, e := range ckp.entries {
+		fs.winDB[e.datKey].loadValue(e.timestamp, e.value)
+	}
+
+	for keys, state := range ckp.states {
+		w, ok := fs.winDB[keys[0]].windows[keys[1]]
+		if ok && w.stateful() {
+			w.restoreState(state)
+		}
+	}
+
+	log.Print("checkpoint restored from: " + fs.checkpointPath())
+
+	return idx, ckp.offset
+}
+
+// validateCheckpoint ensures the checkpoint matches the current data files
+// and window definitions returning the index of the data file it refers to.
+func (fs *fileStore) validateCheckpoint(ckp *checkpoint) (int, error) {
+	idx := slices.IndexFunc(fs.fileHistory, func(n string) bool {
+		return strings.TrimSuffix(n, compressedExtension) == ckp.fName
+	})
+	if idx < 0 {
+		return 0, fmt.Errorf(
+			"%w: unknown data file: %q", ErrInvalidCheckpoint, ckp.fName,
+		)
+	}
+
+	if fs.fileHistory[idx] == ckp.fName {
+		fi, err := os.Stat(fs.filePath(ckp.fName))
+		if err != nil || fi.Size() < ckp.offset {
+			return 0, fmt.Errorf(
+				"%w: data file truncated: %q", ErrInvalidCheckpoint, ckp.fName,
+			)
+		}
+	}
+
+	for datKey := range ckp.data {
+		err := ckp.validateWindows(datKey, fs.winDB[datKey])
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return idx, nil
+}
+
+// validateWindows ensures the key's windows can be restored from the
+// checkpoint.
+func (ckp *checkpoint) validateWindows(datKey string, wdb *winDB) error {
+	if wdb == nil {
+		return nil
+	}
+
+	if wdb.maxPeriod > ckp.periods[datKey] {
+		return fmt.Errorf(
+			"%w: window period increased: %q", ErrInvalidCheckpoint, datKey,
+		)
+	}
+
+	if wdb.maxSamples > ckp.samples[datKey] {
+		return fmt.Errorf(
+			"%w: window samples increased: %q", ErrInvalidCheckpoint, datKey,
+		)
+	}
+
+	for _, winKey := range wdb.winKeys {
+		_, ok := ckp.states[[2]string{datKey, winKey}]
+		if wdb.windows[winKey].stateful() && !ok {
+			return fmt.Errorf(
+				"%w: window added: %q", ErrInvalidCheckpoint, winKey,
+			)
+		}
+	}
+
+	return nil
+}
+
+// readCheckpoint reads and verifies a checkpoint file.  A nil checkpoint is
+// returned if the file does not exist.
+func readCheckpoint(fPath string) (*checkpoint, error) {
+	raw, err := os.ReadFile(fPath) //nolint:gosec // Ok.
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil //nolint:nilnil // Ok.
+	}
+
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Ok.
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	if !strings.HasSuffix(string(raw), "\n") || len(lines) < 2 {
+		return nil, fmt.Errorf("%w: truncated", ErrInvalidCheckpoint)
+	}
+
+	body := string(raw[:len(raw)-len(lines[len(lines)-1])-1])
+
+	if lines[len(lines)-1] != fmt.Sprintf(
+		"%s|%08x", ckpChecksum, crc32.ChecksumIEEE([]byte(body)),
+	) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidCheckpoint)
+	}
+
+	ckp := &checkpoint{
+		data:    make(map[string]*dataPoint),
+		periods: make(map[string]time.Duration),
+		samples: make(map[string]uint64),
+		states:  make(map[[2]string]checkpointState),
+	}
+
+	err = ckp.parseHeader(lines[0])
+
+	for _, line := range lines[1 : len(lines)-1] {
+		if err == nil {
+			err = ckp.parseLine(line)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ckp, nil
+}
+
+func (ckp *checkpoint) parseHeader(line string) error {
+	fields := strings.Split(line, groupSeparator)
+	if len(fields) != ckpHeaderFields ||
+		fields[0] != ckpHeader ||
+		fields[1] != checkpointVersion {
+		return fmt.Errorf("%w: invalid header: %q", ErrInvalidCheckpoint, line)
+	}
+
+	offset, err := strconv.ParseInt(fields[3], base10, 64)
+	if err != nil || offset < 0 {
+		return fmt.Errorf("%w: invalid header: %q", ErrInvalidCheckpoint, line)
+	}
+
+	ckp.fName = fields[2]
+	ckp.offset = offset
+
+	return nil
+}
+
+func (ckp *checkpoint) parseLine(line string) error {
+	fields := strings.Split(line, groupSeparator)
+
+	switch {
+	case fields[0] == ckpData && len(fields) >= ckpDataFields:
+		fields = strings.SplitN(line, groupSeparator, ckpDataFields)
+
+		ts, err := parseTimeStamp(fields[1])
+		if err != nil {
+			return invalidCheckpointLine(line)
+		}
+
+		value, err := decodeValue(recordVersion, fields[3])
+		if err != nil {
+			return invalidCheckpointLine(line)
+		}
+
+		ckp.data[fields[2]] = &dataPoint{TS: ts, Value: value}
+	case fields[0] == ckpPeriod && len(fields) == ckpPeriodFields:
+		period, err := strconv.ParseInt(fields[2], base10, 64)
+		if err != nil {
+			return invalidCheckpointLine(line)
+		}
+
+		samples, err := strconv.ParseUint(fields[3], base10, 64)
+		if err != nil {
+			return invalidCheckpointLine(line)
+		}
+
+		ckp.periods[fields[1]] = time.Duration(period)
+		ckp.samples[fields[1]] = samples
+	case fields[0] == ckpEntry && len(fields) == ckpEntryFields:
+		ts, err := parseTimeStamp(fields[2])
+		if err != nil {
+			return invalidCheckpointLine(line)
+		}
+
+		value, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return invalidCheckpointLine(line)
+		}
+
+		ckp.entries = append(ckp.entries, checkpointEntry{
+			datKey: fields[1], timestamp: ts, value: value,
+		})
+	case fields[0] == ckpState && len(fields) == ckpStateFields:
+		return ckp.parseState(line, fields)
+	default:
+		return invalidCheckpointLine(line)
+	}
+
+	return nil
+}
+
+func (ckp *checkpoint) parseState(line string, fields []string) error {
+	var (
+		state  checkpointState
+		floats [4]float64
+	)
+
+	lastTS, err := parseTimeStamp(fields[3])
+	if err == nil {
+		state.lastTS = lastTS
+		state.count, err = strconv.ParseUint(fields[4], base10, 64)
+	}
+
+	for i := range floats {
+		if err == nil {
+			floats[i], err = strconv.ParseFloat(fields[5+i], 64)
+		}
+	}
+
+	if err != nil {
+		return invalidCheckpointLine(line)
+	}
+
+	state.avg, state.total, state.min, state.max =
+		floats[0], floats[1], floats[2], floats[3]
+	ckp.states[[2]string{fields[1], fields[2]}] = state
+
+	return nil
+}
+
+// restoreState replaces the window's state with that from the checkpoint.
+func (w *window) restoreState(state checkpointState) {
+	w.lastTS = state.lastTS
+	w.count = state.count
+	w.avg = state.avg
+
+	if w.kind == windowTumbling {
+		w.total = state.total
+		w.bucketMin = state.min
+		w.bucketMax = state.max
+		w.bucketEnd = w.bucketStart(state.lastTS).Add(w.period)
+		w.completed = nil
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func invalidCheckpointLine(line string) error {
+	return fmt.Errorf("%w: invalid line: %q", ErrInvalidCheckpoint, line)
+}
+
+func parseTimeStamp(raw string) (time.Time, error) {
+	//nolint:gosmopolitan // Internal logs are all in local time.
+	return time.ParseInLocation(fmtTimeStamp, raw, time.Local)
+}
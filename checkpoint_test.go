@@ -0,0 +1,268 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+// setupCheckpoint creates a store with three values written and closed
+// leaving a checkpoint.  The data file records are then made unreadable
+// (proving they are not replayed) and a fourth record is appended after the
+// checkpoint.
+func setupCheckpoint(chk *sztest.Chk) (string, string) {
+	chk.T().Helper()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	fStore.SetCheckpointInterval(time.Hour)
+	chk.NoErr(fStore.AddWindow("key1", "w1", time.Minute))
+
+	chk.Err(fStore.Checkpoint(), ErrNotOpened.Error())
+
+	chk.NoErr(fStore.Open())                 // clkNano0
+	chk.NoErr(fStore.update("key1", "1", 1)) // clkNano1
+	chk.NoErr(fStore.update("key1", "2", 2)) // clkNano2
+	chk.NoErr(fStore.update("key1", "3", 3)) // clkNano3
+
+	lastTS, _, _ := fStore.get("key1")
+	fPath := fStore.currentFile.Name()
+
+	chk.NoErr(fStore.Close())
+
+	raw, err := os.ReadFile(fPath) //nolint:gosec // Ok.
+	chk.NoErr(err)
+
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.Repeat("X", len(lines[i]))
+	}
+
+	lines = append(lines,
+		lastTS.Add(time.Second).Format(fmtTimeStamp)+`|U|key1|"4"`,
+	)
+
+	chk.NoErr(
+		os.WriteFile(fPath, []byte(strings.Join(lines, "\n")+"\n"), 0o0600),
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+
+	return dirName, filename
+}
+
+func reopenCheckpoint(
+	chk *sztest.Chk, dirName, filename string, period time.Duration,
+) *fileStore {
+	chk.T().Helper()
+
+	fStore := newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	setupFloatValues(fStore)
+	fStore.SetCheckpointInterval(time.Hour)
+	chk.NoErr(fStore.AddWindow("key1", "w1", period))
+	chk.NoErr(fStore.Open())
+
+	return fStore
+}
+
+func TestCheckpoint_RestoreOnOpen(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename := setupCheckpoint(chk)
+
+	fStore := reopenCheckpoint(chk, dirName, filename, time.Minute)
+
+	_, v, ok := fStore.get("key1")
+	chk.True(ok)
+	chk.Str(v, "4")
+
+	count, err := fStore.WindowCount("key1", "w1")
+	chk.NoErr(err)
+	chk.Uint64(count, 4)
+
+	avg, err := fStore.WindowAverage("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(avg, 2.5, 0)
+
+	chk.NoErr(fStore.Close())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`checkpoint restored from: {{dir}}/{{file}}.ckp`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestCheckpoint_Invalid(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename := setupCheckpoint(chk)
+	xLine := strings.Repeat("X", len(`20000515122457.000000000|U|key1|"1"`))
+	ckpPath := dirName + string(os.PathSeparator) + filename +
+		checkpointExtension
+
+	// Window period increased.
+	fStore := reopenCheckpoint(chk, dirName, filename, time.Hour)
+
+	_, v, ok := fStore.get("key1")
+	chk.True(ok)
+	chk.Str(v, "4")
+
+	chk.NoErr(fStore.Close())
+
+	// Corrupted checkpoint.
+	raw, err := os.ReadFile(ckpPath) //nolint:gosec // Ok.
+	chk.NoErr(err)
+
+	raw[0] = 'X'
+	chk.NoErr(os.WriteFile(ckpPath, raw, 0o0600))
+
+	fStore = reopenCheckpoint(chk, dirName, filename, time.Minute)
+	chk.NoErr(fStore.Close())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`checkpoint ignored: invalid checkpoint: window period increased:`+
+			` "key1"`,
+		`splitRecord: invalid number of fields: "1": `+
+			`{{dir}}/{{file}}_20000515.dat:2 - "`+
+			xLine+`"`,
+		`splitRecord: invalid number of fields: "1": `+
+			`{{dir}}/{{file}}_20000515.dat:3 - "`+
+			xLine+`"`,
+		`splitRecord: invalid number of fields: "1": `+
+			`{{dir}}/{{file}}_20000515.dat:4 - "`+
+			xLine+`"`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`checkpoint ignored: invalid checkpoint: checksum mismatch`,
+		`splitRecord: invalid number of fields: "1": `+
+			`{{dir}}/{{file}}_20000515.dat:2 - "`+
+			xLine+`"`,
+		`splitRecord: invalid number of fields: "1": `+
+			`{{dir}}/{{file}}_20000515.dat:3 - "`+
+			xLine+`"`,
+		`splitRecord: invalid number of fields: "1": `+
+			`{{dir}}/{{file}}_20000515.dat:4 - "`+
+			xLine+`"`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestCheckpoint_EWMAState(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	addWindows := func(fStore *fileStore, extra bool) {
+		fStore.SetCheckpointInterval(time.Hour)
+		chk.NoErr(fStore.AddWindow("key1", "w1", time.Second))
+		chk.NoErr(fStore.AddEWMAWindow("key1", "ewma", time.Second*2))
+
+		if extra {
+			chk.NoErr(fStore.AddEWMAWindow("key1", "extra", time.Second))
+		}
+	}
+
+	addWindows(fStore, false)
+	chk.NoErr(fStore.Open())
+
+	for _, v := range []float64{10, 20, 30, 40, 50} {
+		chk.NoErr(fStore.update("key1", "v", v))
+	}
+
+	expected, err := fStore.WindowAverage("key1", "ewma")
+	chk.NoErr(err)
+	chk.NoErr(fStore.Close())
+
+	// The average is restored although most of its samples are not.
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	addWindows(fStore, false)
+	chk.NoErr(fStore.Open())
+
+	got, err := fStore.WindowAverage("key1", "ewma")
+	chk.NoErr(err)
+	chk.Float64(got, expected, 0)
+
+	count, err := fStore.WindowCount("key1", "ewma")
+	chk.NoErr(err)
+	chk.Uint64(count, 5)
+
+	chk.NoErr(fStore.Close())
+
+	// A new EWMA window cannot be restored.
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	addWindows(fStore, true)
+	chk.NoErr(fStore.Open())
+	chk.NoErr(fStore.Close())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`checkpoint restored from: {{dir}}/{{file}}.ckp`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`checkpoint ignored: invalid checkpoint: window added: "extra"`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestCheckpoint_Truncated(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	ckpPath := chk.CreateTmpDir() + string(os.PathSeparator) + "data" +
+		checkpointExtension
+
+	for _, raw := range []string{
+		"",                    // Empty file.
+		"szCheckpoint|1",      // No final newline.
+		"szCheckpoint|1\n",    // Checksum only.
+		"szCheckpoint|1\nabc", // Last line torn.
+	} {
+		chk.NoErr(os.WriteFile(ckpPath, []byte(raw), 0o0600))
+
+		ckp, err := readCheckpoint(ckpPath)
+		chk.Nil(ckp)
+		chk.Err(err, ErrInvalidCheckpoint.Error()+": truncated", raw)
+	}
+}
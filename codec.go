@@ -0,0 +1,127 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// numError classifies a strconv error as either a range or syntax error.
+func numError(err error, raw string) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("%w: %q", ErrInvalidRange, raw)
+	}
+
+	return fmt.Errorf("%w: %q", ErrInvalidSyntax, raw)
+}
+
+// signed lists the signed integer types supported by intCodec.
+type signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// unsigned lists the unsigned integer types supported by uintCodec.
+type unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// floats lists the floating point types supported by floatCodec.
+type floats interface {
+	~float32 | ~float64
+}
+
+type intCodec[T signed] struct {
+	name    string
+	bitSize int
+}
+
+func (c intCodec[T]) Name() string {
+	return c.name
+}
+
+func (c intCodec[T]) Encode(value T) string {
+	return strconv.FormatInt(int64(value), base10)
+}
+
+func (c intCodec[T]) Decode(raw string) (T, error) {
+	value, err := strconv.ParseInt(raw, base10, c.bitSize)
+	if err != nil {
+		return 0, numError(err, raw)
+	}
+
+	return T(value), nil
+}
+
+func (c intCodec[T]) Float(value T) float64 {
+	return float64(value)
+}
+
+type uintCodec[T unsigned] struct {
+	name    string
+	bitSize int
+}
+
+func (c uintCodec[T]) Name() string {
+	return c.name
+}
+
+func (c uintCodec[T]) Encode(value T) string {
+	return strconv.FormatUint(uint64(value), base10)
+}
+
+func (c uintCodec[T]) Decode(raw string) (T, error) {
+	value, err := strconv.ParseUint(raw, base10, c.bitSize)
+	if err != nil {
+		return 0, numError(err, raw)
+	}
+
+	return T(value), nil
+}
+
+func (c uintCodec[T]) Float(value T) float64 {
+	return float64(value)
+}
+
+type floatCodec[T floats] struct {
+	name    string
+	bitSize int
+}
+
+func (c floatCodec[T]) Name() string {
+	return c.name
+}
+
+func (c floatCodec[T]) Encode(value T) string {
+	return strconv.FormatFloat(float64(value), 'f', -1, 64)
+}
+
+func (c floatCodec[T]) Decode(raw string) (T, error) {
+	value, err := strconv.ParseFloat(raw, c.bitSize)
+	if err != nil {
+		return 0, numError(err, raw)
+	}
+
+	return T(value), nil
+}
+
+func (c floatCodec[T]) Float(value T) float64 {
+	return float64(value)
+}
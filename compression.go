@@ -0,0 +1,219 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	compressedExtension = ".gz"
+	temporaryExtension  = ".tmp"
+)
+
+// SetCompression determines if data files are gzip compressed once they are
+// no longer being written to.  Compressed and uncompressed files are read
+// transparently.
+func (fs *fileStore) SetCompression(compress bool) {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	fs.compress = compress
+}
+
+// dataReader wraps an open data file with a decompressor if required.
+type dataReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *dataReader) Close() error {
+	var err error
+
+	for _, c := range slices.Backward(r.closers) {
+		if cErr := c.Close(); err == nil {
+			err = cErr
+		}
+	}
+
+	return err
+}
+
+// openData opens the data file for reading decompressing it if its name
+// ends with the compressed extension.
+func openData(fPath string) (io.ReadCloser, error) {
+	f, err := os.Open(fPath) //nolint:gosec // Ok.
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Ok.
+	}
+
+	if !strings.HasSuffix(fPath, compressedExtension) {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		closeAndLogIfError(f)
+
+		return nil, err //nolint:wrapcheck // Ok.
+	}
+
+	return &dataReader{Reader: gz, closers: []io.Closer{f, gz}}, nil
+}
+
+// catalogCompressed removes compressed names from the list if the
+// uncompressed file also exists (an interrupted compression).
+func catalogCompressed(names []string) []string {
+	return slices.DeleteFunc(names, func(n string) bool {
+		plain, ok := strings.CutSuffix(n, compressedExtension)
+
+		return ok && slices.Contains(names, plain)
+	})
+}
+
+// historyName returns the current name of a file taken from an earlier copy
+// of the file history.  A file compressed since is found under its
+// compressed name.  False is returned if it has left the history.
+func (fs *fileStore) historyName(fName string) (string, bool) {
+	if slices.Contains(fs.fileHistory, fName) {
+		return fName, true
+	}
+
+	gzName := fName + compressedExtension
+	if slices.Contains(fs.fileHistory, gzName) {
+		return gzName, true
+	}
+
+	return "", false
+}
+
+// compressOld compresses all uncompressed history files except the newest.
+func (fs *fileStore) compressOld() {
+	for _, fName := range fs.fileHistory[:len(fs.fileHistory)-1] {
+		if !strings.HasSuffix(fName, compressedExtension) {
+			fs.replaceCompressed(fName, fs.compressFile(fName))
+		}
+	}
+}
+
+// compressInBackground compresses a data file no longer being written to
+// without holding the store's lock.
+func (fs *fileStore) compressInBackground(fName string) {
+	fs.compressing.Add(1)
+
+	go func() {
+		defer fs.compressing.Done()
+
+		ok := fs.compressFile(fName)
+
+		fs.rwMutex.Lock()
+		defer fs.rwMutex.Unlock()
+
+		fs.replaceCompressed(fName, ok)
+	}()
+}
+
+// replaceCompressed swaps the uncompressed file for its compressed version
+// in the file history removing the original.  If the file has since left
+// the history (expired) the compressed version is removed instead.
+func (fs *fileStore) replaceCompressed(fName string, compressed bool) {
+	if !compressed {
+		return
+	}
+
+	gzName := fName + compressedExtension
+	idx := slices.Index(fs.fileHistory, fName)
+
+	if idx < 0 {
+		removeAndLogIfError(fs.filePath(gzName))
+
+		return
+	}
+
+	fs.fileHistory[idx] = gzName
+	removeAndLogIfError(fs.filePath(fName))
+	log.Print("compressed data file: " + fs.filePath(fName))
+}
+
+// compressFile writes a gzip compressed copy of the data file, atomically
+// renaming it into place once complete.
+func (fs *fileStore) compressFile(fName string) bool {
+	fPath := fs.filePath(fName)
+	gzPath := fPath + compressedExtension
+	tmpPath := gzPath + temporaryExtension
+
+	err := writeCompressed(fPath, tmpPath)
+	if err == nil {
+		err = os.Rename(tmpPath, gzPath)
+	}
+
+	if err != nil {
+		log.Print("compress(" + fPath + ") failed: " + err.Error())
+		_ = os.Remove(tmpPath)
+
+		return false
+	}
+
+	return true
+}
+
+func writeCompressed(srcPath, dstPath string) error {
+	src, err := os.Open(srcPath) //nolint:gosec // Ok.
+	if err != nil {
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	defer closeAndLogIfError(src)
+
+	dst, err := os.OpenFile( //nolint:gosec // Ok.
+		dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, defaultFilePermissions,
+	)
+	if err != nil {
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+
+	if err == nil {
+		err = dst.Sync()
+	}
+
+	if cErr := dst.Close(); err == nil {
+		err = cErr
+	}
+
+	return err //nolint:wrapcheck // Ok.
+}
+
+func removeAndLogIfError(fPath string) {
+	err := os.Remove(fPath)
+	if err != nil {
+		log.Print("remove caused: ", err)
+	}
+}
@@ -0,0 +1,203 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestCompression_Rollover(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	maxSize := int64(len(fileHeader())) +
+		int64(len(`20000515122456.000000000|U|key1|"v1"`+"\n"))
+
+	chk.NoErr(fStore.SetMaxFileSize(maxSize))
+	fStore.SetCompression(true)
+
+	chk.NoErr(fStore.Open()) // clkNano0
+
+	for _, v := range []string{"v1", "v2", "v3"} {
+		chk.NoErr(fStore.update("key1", v, 0)) // clkNano1 - clkNano3
+		fStore.compressing.Wait()
+	}
+
+	chk.StrSlice(
+		fStore.fileHistory,
+		[]string{
+			filename + "_20000515" + fileExtension + compressedExtension,
+			filename + "_20000515_000001" + fileExtension +
+				compressedExtension,
+			filename + "_20000515_000002" + fileExtension,
+		},
+	)
+	chk.Int(countFiles(dirName, filename), 3)
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano4
+		[]string{"{{clkNano1}}", "{{clkNano2}}", "{{clkNano3}}"},
+		[]string{"v1", "v2", "v3"},
+	)
+
+	chk.NoErr(fStore.Close())
+
+	// Reopen reading the compressed files.
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano5
+		[]string{"{{clkNano1}}", "{{clkNano2}}", "{{clkNano3}}"},
+		[]string{"v1", "v2", "v3"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`compressed data file: {{dir}}/{{file}}_20000515.dat`,
+		`compressed data file: {{dir}}/{{file}}_20000515_000001.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515_000002.dat`,
+	)
+}
+
+func TestCompression_OnOpen(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 2, dirName, filename, [][2]string{
+			{ /* clkNano0  */ "", "|U|key1|TwoDays"},
+		}),
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 1, dirName, filename, [][2]string{
+			{ /* clkNano1  */ "", "|U|key1|Yesterday"},
+		}),
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{ /* clkNano2  */ "", "|U|key1|Today"},
+		}),
+	)
+
+	// An interrupted compression left an incomplete compressed file.
+	chk.NoErr(
+		os.WriteFile(
+			filepath.Join(
+				dirName,
+				filename+"_20000514"+fileExtension+compressedExtension,
+			),
+			[]byte("bad"),
+			0o0600,
+		),
+	)
+
+	fStore.SetCompression(true)
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.Int(countFiles(dirName, filename), 3)
+
+	validateHistory(chk, fStore, "key1", 2, // clkNano3
+		[]string{"{{clkNano0}}", "{{clkNano1}}", "{{clkNano2}}"},
+		[]string{"TwoDays", "Yesterday", "Today"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`compressed data file: {{hPath2}}`,
+		`compressed data file: {{hPath1}}`,
+		`starting path retrieved as: {{hPath0}}`,
+	)
+}
+
+func TestCompression_DuringScan(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	maxSize := int64(len(fileHeader())) +
+		int64(len(`20000515122456.000000000|U|key1|"v1"`+"\n"))
+
+	chk.NoErr(fStore.SetMaxFileSize(maxSize))
+	fStore.SetCompression(true)
+
+	chk.NoErr(fStore.Open()) // clkNano0
+
+	defer closeAndLogIfError(fStore)
+
+	for _, v := range []string{"v1", "v2"} {
+		chk.NoErr(fStore.update("key1", v, 0)) // clkNano1 - clkNano2
+		fStore.compressing.Wait()
+	}
+
+	var values []string
+
+	fStore.scanHistory("key1", "", "", time.Time{}, time.Time{},
+		func(_ Action, _ time.Time, raw string) {
+			values = append(values, raw)
+		},
+		func() bool {
+			if len(values) == 1 {
+				// Compresses the second file before it is scanned.
+				chk.NoErr(fStore.update("key1", "v3", 0)) // clkNano3
+				fStore.compressing.Wait()
+			}
+
+			return true
+		},
+	)
+
+	chk.StrSlice(values, []string{"v1", "v2"})
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`compressed data file: {{dir}}/{{file}}_20000515.dat`,
+		`compressed data file: {{dir}}/{{file}}_20000515_000001.dat`,
+	)
+}
@@ -0,0 +1,354 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"sync"
+	"time"
+)
+
+// DispatchPolicy indicates what happens to a threshold notification when
+// the dispatcher's queue is full.
+type DispatchPolicy byte
+
+// DispatchPolicy constants.
+const (
+	DispatchBlock DispatchPolicy = 'B'
+	DispatchDrop  DispatchPolicy = 'D'
+)
+
+func (p DispatchPolicy) String() string {
+	switch p {
+	case DispatchBlock:
+		return "B - Block"
+	case DispatchDrop:
+		return "D - Drop"
+	default:
+		return "? - DISPATCH(" + string(p) + ")"
+	}
+}
+
+// IsOK checks that the dispatch policy is valid.
+func (p DispatchPolicy) IsOK() bool {
+	return p == DispatchBlock || p == DispatchDrop
+}
+
+// dispatcher delivers notifications on a worker goroutine.
+// Notifications raised while the store is locked are staged and only
+// queued once the lock is released so a blocked queue never stalls other
+// readers or writers and callbacks may safely access the store.  Staged
+// notifications are always queued oldest first keeping them in order.
+type dispatcher struct {
+	policy    DispatchPolicy
+	queueSize int
+
+	mu         sync.Mutex
+	idle       *sync.Cond // The last notification in flight was delivered.
+	ready      *sync.Cond // A notification was queued (or worker stopped).
+	room       *sync.Cond // A queued notification was taken by the worker.
+	staged     []func()
+	queue      []func()
+	started    bool
+	stopped    bool
+	delivering bool // The worker is running a callback.
+	done       chan struct{}
+	inFlight   int
+	dropped    uint64
+}
+
+// SetNotifyDispatcher delivers all threshold (and stale threshold)
+// notifications asynchronously on a worker goroutine through a queue
+// holding up to queueSize notifications.  When the queue is full
+// DispatchBlock waits for room (without holding any store lock) while
+// DispatchDrop discards the notification.  While a callback is running
+// DispatchBlock does not wait as the callback itself may be updating the
+// store; the notifications are held instead and queued by the worker as it
+// makes room.  It must be set before the store is opened.  Close delivers
+// any notifications still queued.
+func (fs *fileStore) SetNotifyDispatcher(
+	queueSize int, policy DispatchPolicy,
+) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if fs.opened {
+		return ErrOpenedDispatcher
+	}
+
+	if !policy.IsOK() || queueSize < 1 {
+		return ErrInvalidDispatcher
+	}
+
+	if fs.dispatcher != nil {
+		return ErrDispatcherSet
+	}
+
+	fs.dispatcher = newDispatcher(queueSize, policy)
+
+	for _, wdb := range fs.winDB {
+		for _, w := range wdb.windows {
+			fs.dispatcher.wrapAll(w)
+		}
+
+		if wdb.values != nil {
+			fs.dispatcher.wrapAll(wdb.values)
+		}
+	}
+
+	for _, sts := range fs.stale {
+		for _, st := range sts {
+			st.callback = fs.dispatcher.wrapStale(st.callback)
+		}
+	}
+
+	return nil
+}
+
+// Flush blocks until all threshold notifications raised so far have been
+// delivered (or dropped).  It must not be called from a notification
+// callback as the worker would be waiting on itself.
+func (fs *fileStore) Flush() {
+	if fs.dispatcher != nil {
+		fs.dispatcher.flush()
+	}
+}
+
+// DroppedNotifications returns the number of threshold notifications
+// discarded because the dispatcher's queue was full.
+func (fs *fileStore) DroppedNotifications() uint64 {
+	if fs.dispatcher == nil {
+		return 0
+	}
+
+	fs.dispatcher.mu.Lock()
+	defer fs.dispatcher.mu.Unlock()
+
+	return fs.dispatcher.dropped
+}
+
+// stage holds a bucket or stale callback raised while the store is locked
+// to be called by dispatch once the lock is released.
+func (fs *fileStore) stage(notify func()) {
+	fs.stagedMu.Lock()
+	defer fs.stagedMu.Unlock()
+
+	fs.staged = append(fs.staged, notify)
+}
+
+// dispatch calls the callbacks and queues the notifications staged while
+// the store was locked.  It must be called after the lock is released.
+func (fs *fileStore) dispatch() {
+	fs.stagedMu.Lock()
+	staged := fs.staged
+	fs.staged = nil
+	fs.stagedMu.Unlock()
+
+	for _, notify := range staged {
+		notify()
+	}
+
+	if fs.dispatcher != nil {
+		fs.dispatcher.release()
+	}
+}
+
+func newDispatcher(queueSize int, policy DispatchPolicy) *dispatcher {
+	d := new(dispatcher)
+	d.policy = policy
+	d.queueSize = queueSize
+	d.idle = sync.NewCond(&d.mu)
+	d.ready = sync.NewCond(&d.mu)
+	d.room = sync.NewCond(&d.mu)
+
+	return d
+}
+
+// wrap returns a callback staging the notification for the worker.
+func (d *dispatcher) wrap(callback ThresholdNotifyFunc) ThresholdNotifyFunc {
+	return func(datKey, winKey string, from, to ThresholdReason, v float64) {
+		d.stage(func() {
+			callback(datKey, winKey, from, to, v)
+		})
+	}
+}
+
+// wrapAll wraps the callbacks of all the window's thresholds.
+func (d *dispatcher) wrapAll(w *window) {
+	for _, t := range w.thresholds {
+		t.callback = d.wrap(t.callback)
+	}
+}
+
+// wrapStale returns a stale callback staging the notification for the
+// worker.
+func (d *dispatcher) wrapStale(callback StaleNotifyFunc) StaleNotifyFunc {
+	return func(datKey string, stale bool, lastUpdate time.Time) {
+		d.stage(func() {
+			callback(datKey, stale, lastUpdate)
+		})
+	}
+}
+
+// stage holds a notification until the store's lock is released.
+func (d *dispatcher) stage(notify func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.staged = append(d.staged, notify)
+}
+
+// start launches the worker queuing anything staged before it started.
+func (d *dispatcher) start() {
+	d.mu.Lock()
+	if !d.started {
+		d.started = true
+		d.stopped = false
+		d.done = make(chan struct{})
+
+		go d.run(d.done)
+	}
+	d.mu.Unlock()
+
+	d.release()
+}
+
+// stop queues any staged notifications and waits for the worker to
+// deliver everything queued.
+func (d *dispatcher) stop() {
+	d.release()
+
+	d.mu.Lock()
+	done := d.done
+
+	if d.started {
+		d.started = false
+		d.stopped = true
+		d.ready.Broadcast()
+	}
+	d.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// release queues the staged notifications oldest first according to the
+// policy.  They remain staged until the worker is started.  A full queue is
+// not waited on while the worker is running a callback (the caller may be
+// that callback); the worker queues the remaining notifications as it
+// makes room.
+func (d *dispatcher) release() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for d.started && len(d.staged) > 0 {
+		switch {
+		case len(d.queue) < d.queueSize:
+			d.enqueue()
+		case d.policy == DispatchDrop:
+			d.staged = d.staged[1:]
+			d.dropped++
+		case d.delivering:
+			return
+		default:
+			d.room.Wait()
+		}
+	}
+}
+
+// enqueue moves the oldest staged notification to the queue.  The mutex
+// must be held.
+func (d *dispatcher) enqueue() {
+	d.queue = append(d.queue, d.staged[0])
+	d.staged = d.staged[1:]
+	d.inFlight++
+	d.ready.Signal()
+}
+
+// refill queues the notifications left staged while the worker was running
+// a callback as far as there is room.  The mutex must be held.
+func (d *dispatcher) refill() {
+	for len(d.staged) > 0 && len(d.queue) < d.queueSize {
+		d.enqueue()
+	}
+}
+
+// flush releases the staged notifications and waits for them to be
+// delivered.
+func (d *dispatcher) flush() {
+	d.release()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for d.inFlight > 0 || d.started && len(d.staged) > 0 {
+		d.idle.Wait()
+	}
+}
+
+// delivered accounts for a notification no longer in flight.  The mutex
+// must be held.
+func (d *dispatcher) delivered() {
+	d.inFlight--
+	if d.inFlight == 0 {
+		d.idle.Broadcast()
+	}
+}
+
+// next waits for the oldest queued notification returning false once the
+// worker is stopped and the queue is empty.
+func (d *dispatcher) next() (func(), bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for len(d.queue) == 0 {
+		if d.stopped {
+			return nil, false
+		}
+
+		d.ready.Wait()
+	}
+
+	notify := d.queue[0]
+	d.queue = d.queue[1:]
+	d.refill()
+	d.delivering = true
+	d.room.Broadcast()
+
+	return notify, true
+}
+
+func (d *dispatcher) run(done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		notify, ok := d.next()
+		if !ok {
+			return
+		}
+
+		notify()
+
+		d.mu.Lock()
+		d.delivering = false
+		d.refill()
+		d.delivered()
+		d.mu.Unlock()
+	}
+}
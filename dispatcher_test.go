@@ -0,0 +1,216 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestDispatcher_Policy(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	chk.True(DispatchBlock.IsOK())
+	chk.True(DispatchDrop.IsOK())
+	chk.False(DispatchPolicy('X').IsOK())
+
+	chk.Str(DispatchBlock.String(), "B - Block")
+	chk.Str(DispatchDrop.String(), "D - Drop")
+	chk.Str(DispatchPolicy('X').String(), "? - DISPATCH(X)")
+}
+
+func TestDispatcher_Block(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.AddWindow("key1", "w1", time.Minute))
+
+	// Reading the store from a callback would deadlock if invoked while
+	// the store is locked.
+	chk.NoErr(
+		fStore.AddWindowThreshold("key1", "w1", 1, 2, 5, 6,
+			func(d, k string, f, t ThresholdReason, v float64) {
+				_, err := fStore.WindowAverage(d, k)
+				log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g"+
+					" read: %v",
+					d, k, f, t, v, err == nil,
+				)
+			},
+		),
+	)
+
+	chk.Err(
+		fStore.SetNotifyDispatcher(0, DispatchBlock),
+		ErrInvalidDispatcher.Error(),
+	)
+	chk.Err(
+		fStore.SetNotifyDispatcher(1, DispatchPolicy('X')),
+		ErrInvalidDispatcher.Error(),
+	)
+	chk.NoErr(fStore.SetNotifyDispatcher(1, DispatchBlock))
+	chk.Err(
+		fStore.SetNotifyDispatcher(1, DispatchBlock),
+		ErrDispatcherSet.Error(),
+	)
+	chk.NoErr(fStore.Open())
+	chk.Err(
+		fStore.SetNotifyDispatcher(1, DispatchBlock),
+		ErrOpenedDispatcher.Error(),
+	)
+
+	for _, v := range []float64{3, 15, 15} {
+		chk.NoErr(fStore.update("key1", "v", v))
+	}
+
+	fStore.Flush()
+	chk.Uint64(fStore.DroppedNotifications(), 0)
+
+	chk.NoErr(fStore.update("key1", "v", -33))
+	chk.NoErr(fStore.Close()) // Delivers the last notification.
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Threshold("key1","w1"),from: Unknown, to: Normal, value: 3`+
+			` read: true`,
+		`Threshold("key1","w1"),from: Normal, to: High Critical, value: 9`+
+			` read: true`,
+		`Threshold("key1","w1"),from: High Critical, to: Low Critical,`+
+			` value: 0 read: true`,
+	)
+}
+
+func TestDispatcher_Drop(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.SetNotifyDispatcher(1, DispatchDrop))
+	chk.NoErr(fStore.AddCountWindow("key1", "last", 1))
+
+	entered := make(chan struct{})
+	gate := make(chan struct{})
+
+	var reasons []byte
+
+	chk.NoErr(
+		fStore.AddWindowThreshold("key1", "last", 1, 2, 5, 6,
+			func(_, _ string, _, to ThresholdReason, _ float64) {
+				if reasons == nil {
+					close(entered)
+					<-gate
+				}
+
+				reasons = append(reasons, byte(to))
+			},
+		),
+	)
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.NoErr(fStore.update("key1", "v", 0))
+	<-entered // The worker is busy with the first notification.
+
+	for _, v := range []float64{3, 9, 3} {
+		chk.NoErr(fStore.update("key1", "v", v))
+	}
+
+	close(gate)
+	fStore.Flush()
+
+	// Only the first (being delivered) and the second (queued) arrive.
+	chk.Str(string(reasons), "cN")
+	chk.Uint64(fStore.DroppedNotifications(), 2)
+}
+
+func TestDispatcher_CallbackUpdates(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.SetNotifyDispatcher(1, DispatchBlock))
+	chk.NoErr(fStore.AddCountWindow("key1", "last", 1))
+	chk.NoErr(fStore.AddCountWindow("key2", "last", 1))
+
+	notify := func(d, _ string, _, to ThresholdReason, _ float64) {
+		log.Printf("Threshold(%q): %v", d, to)
+	}
+
+	// Each notification for key1 raises more notifications for key2 than
+	// the queue holds.
+	chk.NoErr(
+		fStore.AddWindowThreshold("key1", "last", 1, 2, 5, 6,
+			func(d, k string, f, to ThresholdReason, v float64) {
+				notify(d, k, f, to, v)
+
+				for _, v := range []float64{0, 3, 9} {
+					chk.NoErr(fStore.update("key2", "v", v))
+				}
+			},
+		),
+	)
+	chk.NoErr(fStore.AddWindowThreshold("key2", "last", 1, 2, 5, 6, notify))
+	chk.NoErr(fStore.Open())
+
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		chk.NoErr(fStore.update("key1", "v", 3))
+		fStore.Flush()
+		chk.NoErr(fStore.Close())
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second * 5):
+		t.Fatal("dispatcher deadlocked")
+	}
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Threshold("key1"): Normal`,
+		`Threshold("key2"): Low Critical`,
+		`Threshold("key2"): Normal`,
+		`Threshold("key2"): High Critical`,
+	)
+}
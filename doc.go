@@ -20,7 +20,12 @@
 Package szstore provides for the logging of continuously changing data
 while efficiently maintaining multiple average windows.  Windows may
 register callback functions should certain thresholds be exceeded.  Data
-files are rotated out on a daily bases permitting flexible and predictable
-storage strategies.
+files are rotated out on a daily (or optionally hourly or weekly) bases and
+may be further limited in size, expired and compressed permitting flexible
+and predictable storage strategies.  Periodic checkpoints may be enabled
+so opening a store with a long history only replays the most recent records.
+
+Stores are provided for all the builtin numeric types along with booleans
+and strings.  Other types may be stored by supplying a Codec to New.
 */
 package szstore
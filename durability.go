@@ -0,0 +1,136 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"log"
+	"time"
+)
+
+// Durability indicates when written records are synced to stable storage.
+type Durability byte
+
+// Durability constants.
+const (
+	DurabilityNone     Durability = 'N'
+	DurabilityWrite    Durability = 'W'
+	DurabilityPeriodic Durability = 'P'
+)
+
+func (d Durability) String() string {
+	switch d {
+	case DurabilityNone:
+		return "N - None"
+	case DurabilityWrite:
+		return "W - Write"
+	case DurabilityPeriodic:
+		return "P - Periodic"
+	default:
+		return "? - DURABILITY(" + string(d) + ")"
+	}
+}
+
+// IsOK checks that the durability is valid.
+func (d Durability) IsOK() bool {
+	return d == DurabilityNone ||
+		d == DurabilityWrite ||
+		d == DurabilityPeriodic
+}
+
+// SetDurability determines when records are synced to stable storage.
+// DurabilityNone (the default) leaves it to the operating system,
+// DurabilityWrite syncs after every record and DurabilityPeriodic syncs
+// once the interval has passed since the first unsynced record or the
+// number of unsynced records reaches the provided count.  A zero interval
+// or count disables that trigger but at least one must be provided for
+// periodic syncs.  Pending records are always synced when the data file
+// changes or the store is closed.
+func (fs *fileStore) SetDurability(
+	durability Durability, interval time.Duration, records uint,
+) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if !durability.IsOK() {
+		return ErrInvalidDurability
+	}
+
+	if durability == DurabilityPeriodic && interval <= 0 && records == 0 {
+		return ErrInvalidDurability
+	}
+
+	fs.durability = durability
+	fs.syncInterval = max(interval, 0)
+	fs.syncRecords = records
+
+	return nil
+}
+
+// syncIfDue syncs the current file as required by the durability after a
+// record has been written.
+func (fs *fileStore) syncIfDue() error {
+	switch fs.durability {
+	case DurabilityWrite:
+		fs.pendingSync++
+
+		return fs.syncFile()
+	case DurabilityPeriodic:
+		fs.pendingSync++
+
+		if fs.syncRecords > 0 && fs.pendingSync >= fs.syncRecords {
+			return fs.syncFile()
+		}
+
+		if fs.syncInterval > 0 && fs.syncTimer == nil {
+			fs.syncTimer = time.AfterFunc(fs.syncInterval, fs.syncPeriodic)
+		}
+	default:
+	}
+
+	return nil
+}
+
+// syncPeriodic syncs any pending records once the interval has passed.
+func (fs *fileStore) syncPeriodic() {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if fs.pendingSync > 0 {
+		err := fs.syncFile()
+		if err != nil {
+			log.Print("sync failed: " + err.Error())
+		}
+	}
+}
+
+// syncFile syncs the pending records in the current file.
+func (fs *fileStore) syncFile() error {
+	if fs.syncTimer != nil {
+		fs.syncTimer.Stop()
+		fs.syncTimer = nil
+	}
+
+	if fs.pendingSync == 0 || fs.currentFile == nil {
+		return nil
+	}
+
+	fs.pendingSync = 0
+
+	return fs.currentFile.Sync() //nolint:wrapcheck // Ok.
+}
@@ -0,0 +1,162 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestImpl_Durability(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	chk.True(DurabilityNone.IsOK())
+	chk.True(DurabilityWrite.IsOK())
+	chk.True(DurabilityPeriodic.IsOK())
+	chk.False(Durability('B').IsOK())
+
+	chk.Str(DurabilityNone.String(), "N - None")
+	chk.Str(DurabilityWrite.String(), "W - Write")
+	chk.Str(DurabilityPeriodic.String(), "P - Periodic")
+	chk.Str(Durability('B').String(), "? - DURABILITY(B)")
+}
+
+func TestDurability_InvalidSettings(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.Err(
+		fStore.SetDurability(Durability('B'), 0, 0),
+		ErrInvalidDurability.Error(),
+	)
+
+	chk.Err(
+		fStore.SetDurability(DurabilityPeriodic, 0, 0),
+		ErrInvalidDurability.Error(),
+	)
+
+	chk.NoErr(fStore.SetDurability(DurabilityPeriodic, 0, 1))
+	chk.NoErr(fStore.SetDurability(DurabilityPeriodic, time.Second, 0))
+	chk.NoErr(fStore.SetDurability(DurabilityWrite, 0, 0))
+}
+
+func TestDurability_Write(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.SetDurability(DurabilityWrite, 0, 0))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.NoErr(fStore.update("key1", "v1", 0))
+	chk.Uint(fStore.pendingSync, 0)
+	chk.True(fStore.syncTimer == nil)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestDurability_PeriodicRecords(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.SetDurability(DurabilityPeriodic, time.Hour, 2))
+	chk.NoErr(fStore.Open())
+
+	chk.NoErr(fStore.update("key1", "v1", 0))
+	chk.Uint(fStore.pendingSync, 1)
+	chk.True(fStore.syncTimer != nil)
+
+	chk.NoErr(fStore.update("key1", "v2", 0))
+	chk.Uint(fStore.pendingSync, 0)
+	chk.True(fStore.syncTimer == nil)
+
+	chk.NoErr(fStore.update("key1", "v3", 0))
+	chk.Uint(fStore.pendingSync, 1)
+
+	// Close syncs the pending record.
+	chk.NoErr(fStore.Close())
+	chk.Uint(fStore.pendingSync, 0)
+	chk.True(fStore.syncTimer == nil)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestDurability_PeriodicInterval(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.SetDurability(DurabilityPeriodic, time.Millisecond, 0))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.NoErr(fStore.update("key1", "v1", 0))
+
+	pending := func() uint {
+		fStore.rwMutex.RLock()
+		defer fStore.rwMutex.RUnlock()
+
+		return fStore.pendingSync
+	}
+
+	for i := 0; i < 1000 && pending() > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	chk.Uint(pending(), 0)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
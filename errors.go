@@ -30,24 +30,34 @@ import (
 
 // Public Errors.
 var (
-	ErrInvalidDatKey         = errors.New("invalid data key")
-	ErrUnknownDatKey         = errors.New("unknown data key")
-	ErrUnknownWinKey         = errors.New("unknown window key")
-	ErrDupWinKey             = errors.New("duplicate window key")
-	ErrNoWinData             = errors.New("no window data")
-	ErrUnknownAction         = errors.New("unknown callback action")
-	ErrNilNotifyFunc         = errors.New("invalid nil notify function")
-	ErrInvalidRecord         = errors.New("invalid record")
-	ErrOpenedWindow          = errors.New("invalid add window on opened db")
+	ErrInvalidDatKey = errors.New("invalid data key")
+	ErrUnknownDatKey = errors.New("unknown data key")
+	ErrUnknownWinKey = errors.New("unknown window key")
+	ErrDupWinKey     = errors.New("duplicate window key")
+	ErrNoWinData     = errors.New("no window data")
+	ErrUnknownAction = errors.New("unknown callback action")
+	ErrNilNotifyFunc = errors.New("invalid nil notify function")
+	ErrInvalidRecord = errors.New("invalid record")
+
+	// Deprecated: windows may be added to an opened store so this error is
+	// no longer returned.
+	ErrOpenedWindow = errors.New("invalid add window on opened db")
+
+	// Deprecated: window thresholds may be added to an opened store so this
+	// error is no longer returned.
 	ErrOpenedWindowThreshold = errors.New(
 		"invalid add window threshold on opened db",
 	)
+
 	ErrOpenedWindowNotifyFunc = errors.New(
 		"invalid add window notify function on opened db",
 	)
 	ErrInvalidBoolThreshold = errors.New(
 		"boolean thresholds must be >=0 and <= 1",
 	)
+	ErrUnknownThreshold      = errors.New("unknown window threshold")
+	ErrInvalidDeadband       = errors.New("invalid negative deadband")
+	ErrInvalidDwell          = errors.New("invalid negative dwell")
 	ErrInvalidThresholdOrder = errors.New(
 		"invalid order;" +
 			" need lowCritical <= lowWarning <= highWarning <= highCritical",
@@ -55,6 +65,38 @@ var (
 	ErrInvalidStoreString = errors.New(
 		"invalid store string",
 	)
+	ErrOpenedDispatcher = errors.New(
+		"invalid set notify dispatcher on opened db",
+	)
+	ErrInvalidDispatcher = errors.New(
+		"invalid notify dispatcher queue size or policy",
+	)
+	ErrDispatcherSet = errors.New("notify dispatcher already set")
+	ErrOpenedJournal = errors.New(
+		"invalid set threshold journal on opened db",
+	)
+	ErrNoJournal         = errors.New("threshold journal not enabled")
+	ErrInvalidMaxSilence = errors.New(
+		"invalid stale threshold maximum silence",
+	)
+	ErrOpenedRotation = errors.New(
+		"invalid set rotation on opened db",
+	)
+	ErrInvalidRotation     = errors.New("invalid rotation")
+	ErrInvalidSyntax       = errors.New("invalid syntax")
+	ErrInvalidRange        = errors.New("invalid range")
+	ErrInvalidCharacter    = errors.New("invalid character")
+	ErrInvalidValue        = errors.New("invalid value")
+	ErrNotOpened           = errors.New("db not opened")
+	ErrInvalidCheckpoint   = errors.New("invalid checkpoint")
+	ErrUnsupportedVersion  = errors.New("unsupported record version")
+	ErrInvalidDurability   = errors.New("invalid durability")
+	ErrInvalidPercentile   = errors.New("percentile must be >= 0 and <= 1")
+	ErrInvalidAverageMode  = errors.New("invalid average mode")
+	ErrWindowKind          = errors.New("unsupported by window kind")
+	ErrInvalidBucketPeriod = errors.New(
+		"tumbling period must evenly divide a day",
+	)
 )
 
 func closeAndLogIfError(f io.Closer) {
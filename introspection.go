@@ -0,0 +1,163 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// WindowInfo describes a window and its current state.
+type WindowInfo struct {
+	WinKey     string
+	Kind       string
+	Period     time.Duration // Zero for count windows.
+	Samples    uint64        // Zero unless a count window.
+	Count      uint64
+	Avg        float64
+	Mode       AverageMode
+	Thresholds int
+}
+
+// ThresholdInfo describes a window threshold and its current state.  ID
+// identifies the threshold in the journal by the limits it was added with
+// (unchanged by UpdateWindowThreshold).  Since is the timestamp of the sample
+// that began the current reason (or the store clock's time when the threshold
+// was created if it has not yet been checked).  Pending is the level waiting
+// for its dwell time to elapse since PendingSince (ThresholdUnknown if none).
+type ThresholdInfo struct {
+	ID           string
+	LowCritical  float64
+	LowWarning   float64
+	HighWarning  float64
+	HighCritical float64
+	Deadband     float64
+	Dwell        time.Duration
+	Reason       ThresholdReason
+	Since        time.Time
+	Pending      ThresholdReason
+	PendingSince time.Time
+}
+
+// Keys returns the sorted keys holding a value or having windows.
+func (fs *fileStore) Keys() []string {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	keys := slices.Collect(maps.Keys(fs.winDB))
+	for datKey := range fs.data {
+		if _, ok := fs.winDB[datKey]; !ok {
+			keys = append(keys, datKey)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Windows describes the windows of the specified key sorted by window key.
+func (fs *fileStore) Windows(datKey string) ([]WindowInfo, error) {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return nil, ErrUnknownDatKey
+	}
+
+	infos := make([]WindowInfo, 0, len(dw.winKeys))
+	for _, winKey := range dw.winKeys {
+		infos = append(infos, dw.windows[winKey].info())
+	}
+
+	return infos, nil
+}
+
+// Thresholds describes the thresholds of the specified window in the order
+// they were added.
+func (fs *fileStore) Thresholds(
+	datKey, winKey string,
+) ([]ThresholdInfo, error) {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return nil, ErrUnknownDatKey
+	}
+
+	w, ok := dw.windows[winKey]
+	if !ok {
+		return nil, ErrUnknownWinKey
+	}
+
+	return w.thresholdInfos(), nil
+}
+
+func (w *window) thresholdInfos() []ThresholdInfo {
+	infos := make([]ThresholdInfo, 0, len(w.thresholds))
+	for _, t := range w.thresholds {
+		infos = append(infos, t.info())
+	}
+
+	return infos
+}
+
+func (w *window) info() WindowInfo {
+	info := WindowInfo{
+		WinKey:     w.winKey,
+		Kind:       w.kind.String(),
+		Period:     w.period,
+		Samples:    w.samples,
+		Mode:       w.mode,
+		Thresholds: len(w.thresholds),
+	}
+
+	if w.kind == windowCount {
+		info.Period = 0
+	}
+
+	info.Count, _ = w.getCount()
+	info.Avg, _ = w.getAvg()
+
+	return info
+}
+
+func (d *threshold) info() ThresholdInfo {
+	info := ThresholdInfo{
+		ID:           d.id,
+		LowCritical:  d.lowCritical,
+		LowWarning:   d.lowWarning,
+		HighWarning:  d.highWarning,
+		HighCritical: d.highCritical,
+		Deadband:     d.deadband,
+		Dwell:        d.dwell,
+		Reason:       d.currentReason,
+		Since:        d.since,
+		Pending:      d.pendingReason,
+	}
+
+	if info.Pending != ThresholdUnknown {
+		info.PendingSince = d.started
+	}
+
+	return info
+}
@@ -0,0 +1,185 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestIntrospection_WindowKind(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	chk.Str(windowSliding.String(), "S - Sliding")
+	chk.Str(windowEWMA.String(), "E - EWMA")
+	chk.Str(windowCount.String(), "N - Count")
+	chk.Str(windowTumbling.String(), "B - Tumbling")
+	chk.Str(windowRate.String(), "R - Rate")
+	chk.Str(windowKind('X').String(), "? - WINDOW(X)")
+}
+
+func TestIntrospection_Describe(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.AddWindow("key2", "slide", time.Minute))
+	chk.NoErr(fStore.AddCountWindow("key2", "last2", 2))
+	chk.NoErr(fStore.AddEWMAWindow("key2", "ewma", time.Minute))
+	chk.NoErr(
+		fStore.AddWindowThreshold("key2", "slide", 1, 2, 5, 6,
+			func(_, _ string, _, _ ThresholdReason, _ float64) {},
+		),
+	)
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.NoErr(fStore.update("key1", "a", 0))
+
+	for _, v := range []float64{2, 4, 6} {
+		chk.NoErr(fStore.update("key2", "v", v))
+	}
+
+	chk.Str(strings.Join(fStore.Keys(), ","), "key1,key2")
+
+	_, err := fStore.Windows("unknown")
+	chk.Err(err, ErrUnknownDatKey.Error())
+
+	infos, err := fStore.Windows("key2")
+	chk.NoErr(err)
+	chk.Int(len(infos), 3)
+
+	chk.Str(infos[0].WinKey, "ewma")
+	chk.Str(infos[0].Kind, "E - EWMA")
+	chk.Int(int(infos[0].Period), int(time.Minute))
+
+	chk.Str(infos[1].WinKey, "last2")
+	chk.Str(infos[1].Kind, "N - Count")
+	chk.Int(int(infos[1].Period), 0)
+	chk.Uint64(infos[1].Samples, 2)
+	chk.Uint64(infos[1].Count, 2)
+	chk.Float64(infos[1].Avg, 5, 0)
+
+	chk.Str(infos[2].WinKey, "slide")
+	chk.Str(infos[2].Kind, "S - Sliding")
+	chk.Uint64(infos[2].Count, 3)
+	chk.Float64(infos[2].Avg, 4, 0)
+	chk.Str(infos[2].Mode.String(), "S - Sample")
+	chk.Int(infos[2].Thresholds, 1)
+
+	_, err = fStore.Thresholds("unknown", "slide")
+	chk.Err(err, ErrUnknownDatKey.Error())
+
+	_, err = fStore.Thresholds("key2", "unknown")
+	chk.Err(err, ErrUnknownWinKey.Error())
+
+	thresholds, err := fStore.Thresholds("key2", "slide")
+	chk.NoErr(err)
+	chk.Int(len(thresholds), 1)
+	chk.Float64(thresholds[0].LowCritical, 1, 0)
+	chk.Float64(thresholds[0].LowWarning, 2, 0)
+	chk.Float64(thresholds[0].HighWarning, 5, 0)
+	chk.Float64(thresholds[0].HighCritical, 6, 0)
+	chk.Str(thresholds[0].Reason.String(), "Normal")
+
+	// Normal since the second sample brought the average up to 3.
+	second := fStore.winDB["key2"].newestEntry.next
+	chk.Float64(second.value, 4, 0)
+	chk.True(thresholds[0].Since.Equal(second.timestamp))
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestIntrospection_PendingThreshold(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.AddWindow("key1", "slide", time.Minute))
+	chk.NoErr(
+		fStore.AddWindowThreshold("key1", "slide", 1, 2, 5, 6,
+			func(_, _ string, _, _ ThresholdReason, _ float64) {},
+		),
+	)
+
+	// An unchecked threshold dates from its creation by the store clock.
+	thresholds, err := fStore.Thresholds("key1", "slide")
+	chk.NoErr(err)
+	chk.True(
+		thresholds[0].Since.Equal(
+			time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		),
+	)
+
+	chk.NoErr(
+		fStore.SetWindowThresholdDwell("key1", "slide", 0, time.Second*5),
+	)
+	chk.Err(
+		fStore.SetWindowThresholdDwell("key1", "slide", 0, -time.Second),
+		ErrInvalidDwell.Error(),
+	)
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.NoErr(fStore.update("key1", "v", 3))
+
+	began, _, _ := fStore.get("key1")
+
+	thresholds, err = fStore.Thresholds("key1", "slide")
+	chk.NoErr(err)
+	chk.Int(int(thresholds[0].Dwell), int(time.Second*5))
+	chk.Str(thresholds[0].Reason.String(), "Unknown")
+	chk.Str(thresholds[0].Pending.String(), "Normal")
+	chk.True(thresholds[0].PendingSince.Equal(began))
+
+	for range 5 {
+		chk.NoErr(fStore.update("key1", "v", 3))
+	}
+
+	thresholds, err = fStore.Thresholds("key1", "slide")
+	chk.NoErr(err)
+	chk.Str(thresholds[0].Reason.String(), "Normal")
+	chk.Str(thresholds[0].Pending.String(), "Unknown")
+	chk.True(thresholds[0].Since.Equal(began))
+	chk.True(thresholds[0].PendingSince.IsZero())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
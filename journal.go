@@ -0,0 +1,360 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	journalExtension = ".thr"
+	journalFields    = 7
+	journalReasons   = "UcwNWC"
+)
+
+// ThresholdEvent records a threshold transition read from the journal.  The
+// ID identifies the threshold as reported by Thresholds (or ValueThresholds
+// if OnValue is set in which case WinKey is empty).
+type ThresholdEvent struct {
+	Timestamp time.Time
+	DatKey    string
+	WinKey    string
+	OnValue   bool
+	ID        string
+	From      ThresholdReason
+	To        ThresholdReason
+	Value     float64
+}
+
+// journalKey identifies a threshold across restarts.
+type journalKey struct {
+	datKey  string
+	winKey  string
+	onValue bool
+	id      string
+}
+
+// SetThresholdJournal records every threshold transition in journal files
+// alongside the data files (one per rotation period).  On Open each
+// threshold's current reason is restored from the journal matching the
+// threshold by its ID (see ThresholdInfo).  It must be set before the store
+// is opened.
+func (fs *fileStore) SetThresholdJournal(enabled bool) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if fs.opened {
+		return ErrOpenedJournal
+	}
+
+	fs.journal = enabled
+
+	return nil
+}
+
+// GetThresholdHistory returns the journaled transitions of the specified
+// window's thresholds made between from and to inclusive.  Events of the
+// window's different thresholds are distinguished by their ID.
+func (fs *fileStore) GetThresholdHistory(
+	datKey, winKey string, from, to time.Time,
+) ([]ThresholdEvent, error) {
+	return fs.thresholdHistory(datKey, winKey, false, from, to)
+}
+
+// GetValueThresholdHistory returns the journaled transitions of the key's
+// value thresholds made between from and to inclusive.
+func (fs *fileStore) GetValueThresholdHistory(
+	datKey string, from, to time.Time,
+) ([]ThresholdEvent, error) {
+	return fs.thresholdHistory(datKey, "", true, from, to)
+}
+
+func (fs *fileStore) thresholdHistory(
+	datKey, winKey string, onValue bool, from, to time.Time,
+) ([]ThresholdEvent, error) {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	if !fs.journal {
+		return nil, ErrNoJournal
+	}
+
+	var events []ThresholdEvent
+
+	if to.Before(from) {
+		return events, nil
+	}
+
+	minStamp, maxStamp := fs.rangeStamps(from, to)
+
+	for _, fName := range fs.journalHistory {
+		stamp := fs.journalStamp(fName)
+		if stamp < minStamp || stamp > maxStamp {
+			continue
+		}
+
+		err := fs.readJournal(fName, func(e ThresholdEvent) {
+			if e.DatKey == datKey && e.WinKey == winKey &&
+				e.OnValue == onValue &&
+				!e.Timestamp.Before(from) && !e.Timestamp.After(to) {
+				events = append(events, e)
+			}
+		})
+		if err != nil {
+			return events, err
+		}
+	}
+
+	return events, nil
+}
+
+// openJournal catalogs the journal files restoring the thresholds' last
+// reasons from them before recording any new transitions.
+func (fs *fileStore) openJournal() error {
+	allFiles, err := os.ReadDir(fs.dirName)
+	if err != nil {
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	fs.journalHistory = nil
+
+	for _, fileInf := range allFiles {
+		if fs.journalStamp(fileInf.Name()) != "" {
+			fs.journalHistory = append(fs.journalHistory, fileInf.Name())
+		}
+	}
+
+	if fs.retentionSet() {
+		fs.expireJournals()
+	}
+
+	last := make(map[journalKey]ThresholdEvent)
+
+	for _, fName := range fs.journalHistory {
+		err = fs.readJournal(fName, func(e ThresholdEvent) {
+			last[journalKey{e.DatKey, e.WinKey, e.OnValue, e.ID}] = e
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, wdb := range fs.winDB {
+		for _, w := range wdb.windows {
+			fs.restoreJournal(w, last)
+		}
+
+		if wdb.values != nil {
+			fs.restoreJournal(wdb.values, last)
+		}
+	}
+
+	return nil
+}
+
+// restoreJournal restores the window's thresholds' last journaled reasons
+// and starts journaling their transitions.
+func (fs *fileStore) restoreJournal(
+	w *window, last map[journalKey]ThresholdEvent,
+) {
+	for _, t := range w.thresholds {
+		if e, ok := last[journalKey{w.datKey, w.winKey, w.values, t.id}]; ok {
+			t.currentReason = e.To
+			t.pendingReason = ThresholdUnknown
+			t.started = e.Timestamp
+			t.since = e.Timestamp
+		}
+
+		fs.journalThreshold(w, t)
+	}
+}
+
+// journalThreshold records the threshold's transitions if journaling.
+func (fs *fileStore) journalThreshold(w *window, t *threshold) {
+	if fs.journal {
+		t.journal = func(from, to ThresholdReason, value float64) {
+			fs.writeJournal(w, t, from, to, value)
+		}
+	}
+}
+
+// activateThreshold records the creation of the window's newest threshold
+// by the store clock and checks it against the window's current average.
+// Thresholds added before the store is opened are not journaled until
+// openJournal restores them so the replayed history is not recorded again.
+func (fs *fileStore) activateThreshold(w *window) {
+	t := w.thresholds[len(w.thresholds)-1]
+	t.since = fs.ts()
+	t.started = t.since
+
+	if fs.opened {
+		fs.journalThreshold(w, t)
+	}
+
+	w.recheck(t)
+}
+
+// writeJournal appends a transition to the journal.  The window key is
+// quoted leaving the field empty for value thresholds.
+func (fs *fileStore) writeJournal(
+	w *window, t *threshold, from, to ThresholdReason, value float64,
+) {
+	winKey := ""
+	if !w.values {
+		winKey = strconv.Quote(w.winKey)
+	}
+
+	record := fmt.Sprintf("%s|%s|%s|%c|%c|%s|%s\n",
+		t.since.Format(fmtTimeStamp), w.datKey,
+		t.id, from, to, formatFloat(value), winKey,
+	)
+
+	err := fs.openJournalFile(fs.rotation.stamp(t.since))
+	if err == nil {
+		_, err = fs.journalFile.WriteString(record)
+	}
+
+	if err != nil {
+		log.Print("journal: " + err.Error())
+	}
+}
+
+// openJournalFile opens (or creates) the journal file for the stamp.
+func (fs *fileStore) openJournalFile(stamp string) error {
+	if fs.journalFile != nil && fs.journalFileStamp == stamp {
+		return nil
+	}
+
+	fs.closeJournal()
+
+	fName := fs.filenameRoot + "_" + stamp + journalExtension
+
+	f, err := os.OpenFile(
+		fs.filePath(fName),
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
+		defaultFilePermissions,
+	)
+	if err != nil {
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	if !slices.Contains(fs.journalHistory, fName) {
+		fs.journalHistory = append(fs.journalHistory, fName)
+		slices.Sort(fs.journalHistory)
+	}
+
+	fs.journalFile = f
+	fs.journalFileStamp = stamp
+
+	return nil
+}
+
+func (fs *fileStore) closeJournal() {
+	if fs.journalFile != nil {
+		closeAndLogIfError(fs.journalFile)
+	}
+
+	fs.journalFile = nil
+	fs.journalFileStamp = ""
+}
+
+// journalStamp returns the period stamp of a journal file belonging to this
+// store (or an empty string if it is not one).
+func (fs *fileStore) journalStamp(fName string) string {
+	stamp, ok := strings.CutPrefix(fName, fs.filenameRoot+"_")
+	if ok {
+		stamp, ok = strings.CutSuffix(stamp, journalExtension)
+	}
+
+	if !ok || !fs.rotation.validStamp(stamp) {
+		return ""
+	}
+
+	return stamp
+}
+
+// readJournal passes each valid event in the journal file to add.
+func (fs *fileStore) readJournal(
+	fName string, add func(ThresholdEvent),
+) error {
+	f, err := os.Open(fs.filePath(fName))
+	if err != nil {
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	defer closeAndLogIfError(f)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e, ok := parseJournalRecord(scanner.Text())
+		if ok {
+			add(e)
+		} else {
+			log.Printf(
+				"journal: invalid record in %s: %q", fName, scanner.Text(),
+			)
+		}
+	}
+
+	return scanner.Err() //nolint:wrapcheck // Ok.
+}
+
+func parseJournalRecord(record string) (ThresholdEvent, bool) {
+	var e ThresholdEvent
+
+	fields := strings.SplitN(record, groupSeparator, journalFields)
+	if len(fields) != journalFields {
+		return e, false
+	}
+
+	timestamp, err := parseTimeStamp(fields[0])
+	if err == nil {
+		e.Value, err = strconv.ParseFloat(fields[5], 64)
+	}
+
+	if err == nil && fields[6] == "" {
+		e.OnValue = true
+	} else if err == nil {
+		e.WinKey, err = strconv.Unquote(fields[6])
+	}
+
+	if err != nil || fields[2] == "" || !validJournalReason(fields[3]) ||
+		!validJournalReason(fields[4]) {
+		return e, false
+	}
+
+	e.Timestamp = timestamp
+	e.DatKey = fields[1]
+	e.ID = fields[2]
+	e.From = ThresholdReason(fields[3][0])
+	e.To = ThresholdReason(fields[4][0])
+
+	return e, true
+}
+
+func validJournalReason(field string) bool {
+	return len(field) == 1 && strings.Contains(journalReasons, field)
+}
@@ -0,0 +1,330 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestJournal_RestoreAndHistory(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	notify := func(d, k string, f, t ThresholdReason, v float64) {
+		log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
+			d, k, f, t, v,
+		)
+	}
+
+	configure := func(fStore *fileStore) {
+		setupFloatValues(fStore)
+
+		chk.NoErr(fStore.SetThresholdJournal(true))
+		chk.NoErr(fStore.AddWindow("key1", "w1", time.Minute))
+		chk.NoErr(
+			fStore.AddWindowThreshold("key1", "w1", 1, 2, 5, 6, notify),
+		)
+		chk.NoErr(fStore.AddValueThreshold("key1", 1, 2, 7, 8, notify))
+	}
+
+	configure(fStore)
+
+	_, err := fStore.GetThresholdHistory(
+		"key1", "w1", time.Time{}, time.Now(),
+	)
+	chk.NoErr(err)
+
+	chk.NoErr(fStore.Open())
+	chk.Err(fStore.SetThresholdJournal(false), ErrOpenedJournal.Error())
+
+	chk.NoErr(fStore.update("key1", "3", 3))
+	chk.NoErr(fStore.update("key1", "9", 9))
+
+	lastTS, _, _ := fStore.get("key1")
+
+	chk.NoErr(fStore.update("key1", "9", 9))
+	chk.NoErr(fStore.Close())
+
+	// Restored reasons are not reported again.
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	configure(fStore)
+	chk.NoErr(fStore.Open())
+
+	thresholds, err := fStore.Thresholds("key1", "w1")
+	chk.NoErr(err)
+	chk.Str(thresholds[0].Reason.String(), "High Critical")
+	chk.True(thresholds[0].Since.Equal(lastTS))
+
+	chk.NoErr(fStore.update("key1", "9", 9))
+
+	events, err := fStore.GetThresholdHistory(
+		"key1", "w1", time.Time{}, lastTS.Add(time.Hour),
+	)
+	chk.NoErr(err)
+	chk.Int(len(events), 2)
+	chk.Str(events[0].From.String(), "Unknown")
+	chk.Str(events[0].To.String(), "Normal")
+	chk.Float64(events[0].Value, 3, 0)
+	chk.Str(events[1].To.String(), "High Critical")
+	chk.Float64(events[1].Value, 6, 0)
+	chk.True(events[1].Timestamp.Equal(lastTS))
+	chk.Str(events[1].WinKey, "w1")
+	chk.Str(events[1].ID, "1:2:5:6")
+
+	events, err = fStore.GetValueThresholdHistory(
+		"key1", time.Time{}, lastTS,
+	)
+	chk.NoErr(err)
+	chk.Int(len(events), 2)
+	chk.True(events[1].OnValue)
+	chk.Str(events[1].To.String(), "High Critical")
+	chk.Float64(events[1].Value, 9, 0)
+
+	events, err = fStore.GetValueThresholdHistory(
+		"key1", time.Time{}, lastTS.Add(-time.Nanosecond),
+	)
+	chk.NoErr(err)
+	chk.Int(len(events), 1)
+
+	events, err = fStore.GetValueThresholdHistory(
+		"key1", lastTS, time.Time{},
+	)
+	chk.NoErr(err)
+	chk.Int(len(events), 0)
+
+	chk.NoErr(fStore.Close())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Threshold("key1","w1"),from: Unknown, to: Normal, value: 3`,
+		`Threshold("key1",""),from: Unknown, to: Normal, value: 3`,
+		`Threshold("key1","w1"),from: Normal, to: High Critical, value: 6`,
+		`Threshold("key1",""),from: Normal, to: High Critical, value: 9`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestJournal_Invalid(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	_, err := fStore.GetThresholdHistory(
+		"key1", "w1", time.Time{}, time.Now(),
+	)
+	chk.Err(err, ErrNoJournal.Error())
+
+	chk.NoErr(
+		os.WriteFile(
+			dirName+"/dataFile_20000515.thr",
+			[]byte(""+
+				"20000515122456.000000000|key1|1:2:5:6|U|N|3|\"w1\"\n"+
+				"20000515122456.000000000|key1|1:2:5:6|U|X|3|\"w1\"\n"+
+				"bad\n",
+			),
+			defaultFilePermissions,
+		),
+	)
+
+	chk.NoErr(fStore.SetThresholdJournal(true))
+	chk.NoErr(fStore.AddWindow("key1", "w1", time.Minute))
+	chk.NoErr(
+		fStore.AddWindowThreshold("key1", "w1", 1, 2, 5, 6,
+			func(_, _ string, _, _ ThresholdReason, _ float64) {},
+		),
+	)
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	thresholds, err := fStore.Thresholds("key1", "w1")
+	chk.NoErr(err)
+	chk.Str(thresholds[0].Reason.String(), "Normal")
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`journal: invalid record in {{file}}_20000515.thr: `+
+			`"20000515122456.000000000|key1|1:2:5:6|U|X|3|\"w1\""`,
+		`journal: invalid record in {{file}}_20000515.thr: "bad"`,
+	)
+}
+
+func TestJournal_RestoreByID(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	notify := func(d, k string, f, t ThresholdReason, v float64) {
+		log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
+			d, k, f, t, v,
+		)
+	}
+
+	configure := func(fStore *fileStore, limits ...[4]float64) {
+		chk.NoErr(fStore.SetThresholdJournal(true))
+		chk.NoErr(fStore.AddCountWindow("key1", "last", 1))
+
+		for _, l := range limits {
+			chk.NoErr(
+				fStore.AddWindowThreshold(
+					"key1", "last", l[0], l[1], l[2], l[3], notify,
+				),
+			)
+		}
+	}
+
+	low := [4]float64{1, 2, 3, 4}
+	high := [4]float64{10, 20, 30, 40}
+
+	configure(fStore, low, high, high)
+	chk.NoErr(fStore.Open())
+	chk.NoErr(fStore.update("key1", "v", 5))
+	chk.NoErr(fStore.Close())
+
+	// Registered in a different order with a duplicate removed each
+	// threshold recovers its own reason.
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	configure(fStore, high, high, low)
+	chk.NoErr(fStore.RemoveWindowThreshold("key1", "last", 1))
+	chk.NoErr(fStore.Open())
+
+	thresholds, err := fStore.Thresholds("key1", "last")
+	chk.NoErr(err)
+	chk.Int(len(thresholds), 2)
+	chk.Str(thresholds[0].ID, "10:20:30:40")
+	chk.Str(thresholds[0].Reason.String(), "Low Critical")
+	chk.Str(thresholds[1].ID, "1:2:3:4")
+	chk.Str(thresholds[1].Reason.String(), "High Critical")
+
+	events, err := fStore.GetThresholdHistory(
+		"key1", "last", time.Time{}, time.Now(),
+	)
+	chk.NoErr(err)
+	chk.Int(len(events), 3)
+
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	chk.StrSlice(ids, []string{"1:2:3:4", "10:20:30:40", "10:20:30:40#1"})
+
+	chk.NoErr(fStore.Close())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Threshold("key1","last"),from: Unknown, to: High Critical, value: 5`,
+		`Threshold("key1","last"),from: Unknown, to: Low Critical, value: 5`,
+		`Threshold("key1","last"),from: Unknown, to: Low Critical, value: 5`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestJournal_ReplayNotJournaled(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	notify := func(d, k string, f, t ThresholdReason, v float64) {
+		log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
+			d, k, f, t, v,
+		)
+	}
+
+	configure := func(fStore *fileStore) {
+		setupFloatValues(fStore)
+
+		// Journaling is enabled before the thresholds are added.
+		chk.NoErr(fStore.SetThresholdJournal(true))
+		fStore.SetReplayThresholds(true)
+		chk.NoErr(fStore.AddCountWindow("key1", "last", 1))
+		chk.NoErr(
+			fStore.AddWindowThreshold("key1", "last", 1, 2, 5, 6, notify),
+		)
+	}
+
+	configure(fStore)
+	chk.NoErr(fStore.Open())
+	chk.NoErr(fStore.update("key1", "3", 3))
+	chk.NoErr(fStore.update("key1", "9", 9))
+	chk.NoErr(fStore.Close())
+
+	for range 2 {
+		fStore = newFileStore(dirName, filename)
+		fStore.ts = chk.ClockNext
+		configure(fStore)
+		chk.NoErr(fStore.Open())
+
+		events, err := fStore.GetThresholdHistory(
+			"key1", "last", time.Time{}, time.Now(),
+		)
+		chk.NoErr(err)
+		chk.Int(len(events), 2)
+
+		chk.NoErr(fStore.Close())
+	}
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Threshold("key1","last"),from: Unknown, to: Normal, value: 3`,
+		`Threshold("key1","last"),from: Normal, to: High Critical, value: 9`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`Threshold("key1","last"),from: Unknown, to: Normal, value: 3`,
+		`Threshold("key1","last"),from: Normal, to: High Critical, value: 9`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`Threshold("key1","last"),from: Unknown, to: Normal, value: 3`,
+		`Threshold("key1","last"),from: Normal, to: High Critical, value: 9`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
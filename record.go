@@ -0,0 +1,131 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Data file record versions.  Legacy files have no header and store values
+// verbatim.  Later versions start with a header line identifying the version.
+const (
+	recordVersionLegacy = 1 // Values stored verbatim.
+	recordVersion       = 2 // Values stored as Go quoted strings.
+	fileHeaderPrefix    = "#szstore" + groupSeparator
+)
+
+// fileHeader returns the header line written to the start of new files.
+func fileHeader() string {
+	return fileHeaderPrefix + strconv.Itoa(recordVersion) + "\n"
+}
+
+// encodeValue returns the value as written in a record of the current
+// version.  Quoting permits any value (including separators, newlines and
+// invalid UTF-8) to be stored.
+func encodeValue(value string) string {
+	return strconv.Quote(value)
+}
+
+// decodeValue returns the value stored in a record of the provided version.
+func decodeValue(version int, raw string) (string, error) {
+	if version == recordVersionLegacy {
+		return raw, nil
+	}
+
+	value, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSyntax, raw)
+	}
+
+	return value, nil
+}
+
+// parseHeader returns the record version identified by a file's first line.
+// Files without a header are legacy files.
+func parseHeader(line string) (int, bool, error) {
+	rawVersion, ok := strings.CutPrefix(line, fileHeaderPrefix)
+	if !ok {
+		return recordVersionLegacy, false, nil
+	}
+
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil ||
+		version <= recordVersionLegacy ||
+		version > recordVersion {
+		return 0, true, fmt.Errorf("%w: %q", ErrUnsupportedVersion, line)
+	}
+
+	return version, true, nil
+}
+
+// record formats a record for the version of the current file.
+func (fs *fileStore) record(
+	timestamp time.Time, action Action, key, value string,
+) string {
+	if fs.currentFileVer == recordVersion {
+		value = encodeValue(value)
+	}
+
+	return fmt.Sprintf(
+		"%s|%c|%s|%s\n", timestamp.Format(fmtTimeStamp), action, key, value,
+	)
+}
+
+// canAppend reports if the value may be appended to the current file.
+// Existing legacy files continue to be appended to until a value they
+// cannot represent is written.
+func (fs *fileStore) canAppend(value string) bool {
+	return fs.currentFileVer == recordVersion ||
+		fs.currentFileVer == recordVersionLegacy &&
+			!strings.ContainsAny(value, "\r\n")
+}
+
+// readHeader checks the first line of the data file being read for a
+// header setting the record version accordingly.  True is returned if the
+// line was a header and should not be processed as a record.
+func (fs *fileStore) readHeader(line string) bool {
+	if fs.fLineNum != 1 {
+		return false
+	}
+
+	version, isHeader, err := parseHeader(line)
+	if err != nil {
+		fs.logMsg("readHeader: " + err.Error())
+	}
+
+	fs.fVersion = version
+
+	return isHeader
+}
+
+// readFileVersion returns the record version of an existing data file.  An
+// empty file has no version.
+func readFileVersion(scanner *bufio.Scanner) (int, error) {
+	if !scanner.Scan() {
+		return 0, scanner.Err() //nolint:wrapcheck // Ok.
+	}
+
+	version, _, err := parseHeader(scanner.Text())
+
+	return version, err
+}
@@ -0,0 +1,181 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestRecord_EncodeRoundTrip(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	values := []string{
+		"multi\nline\r\nstatus",
+		"has|separator",
+		`back\slash "quoted"`,
+		"binary\x00\xff\xfe",
+		"unicode ✓",
+		"",
+	}
+
+	chk.NoErr(fStore.Open()) // clkNano0
+
+	for _, v := range values {
+		chk.NoErr(fStore.update("key1", v, 0)) // clkNano1 - clkNano6
+	}
+
+	chk.Err(
+		fStore.update("bad\nkey", "value", 0),
+		ErrInvalidDatKey.Error(),
+	)
+
+	chk.NoErr(fStore.Close())
+
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.Int(countFiles(dirName, filename), 1)
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano7
+		[]string{
+			"{{clkNano1}}", "{{clkNano2}}", "{{clkNano3}}",
+			"{{clkNano4}}", "{{clkNano5}}", "{{clkNano6}}",
+		},
+		values,
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`update(key="bad\nkey",value="value") invalid key`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestRecord_LegacyFileAppend(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{ /* clkNano0  */ "", `|U|key1|"legacy"|value`},
+		}),
+	)
+
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	// Values legacy files can hold are still appended.
+	chk.NoErr(fStore.update("key1", "plain", 0)) // clkNano1
+	chk.Int(countFiles(dirName, filename), 1)
+
+	// Values requiring encoding start a new file.
+	chk.NoErr(fStore.update("key1", "multi\nline", 0)) // clkNano2
+	chk.Int(countFiles(dirName, filename), 2)
+
+	chk.StrSlice(
+		fStore.fileHistory,
+		[]string{
+			filename + "_20000515" + fileExtension,
+			filename + "_20000515_000001" + fileExtension,
+		},
+	)
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano3
+		[]string{"{{clkNano0}}", "{{clkNano1}}", "{{clkNano2}}"},
+		[]string{`"legacy"|value`, "plain", "multi\nline"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{hPath0}}`,
+	)
+}
+
+func TestRecord_UnsupportedVersion(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{fileHeaderPrefix + "9", ""},
+			{ /* clkNano0  */ "", `|U|key1|"future"`},
+		}),
+	)
+
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	_, _, ok := fStore.get("key1")
+	chk.False(ok)
+
+	// The unsupported file is never appended to.
+	chk.NoErr(fStore.update("key1", "current", 0)) // clkNano1
+	chk.Int(countFiles(dirName, filename), 2)
+
+	ts, _, _ := fStore.get("key1")
+
+	raw, err := os.ReadFile(
+		dirName + string(os.PathSeparator) +
+			filename + "_20000515_000001" + fileExtension,
+	)
+	chk.NoErr(err)
+	chk.Str(
+		string(raw),
+		fileHeader()+ts.Format(fmtTimeStamp)+`|U|key1|"current"`+"\n",
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`readHeader: unsupported record version: "#szstore|9": `+
+			`{{hPath0}}:1 - "#szstore|9"`,
+		`starting path retrieved as: {{hPath0}}`,
+		`setFileVersion: unsupported record version: "#szstore|9"`,
+		`get("key1"): unknown data key`,
+	)
+}
@@ -0,0 +1,161 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+const tailBlockSize = 4096
+
+// RecoveryReport describes a repair made to the newest data file when the
+// store was opened.  A torn (partially written) trailing record left by a
+// crash or power loss is removed by truncating the file after the last
+// complete record.
+type RecoveryReport struct {
+	Path      string // The data file repaired.
+	Size      int64  // The size of the file before it was repaired.
+	Offset    int64  // The size the file was truncated to.
+	Discarded string // The partial record removed.
+}
+
+// Repaired reports if a repair was made.
+func (r RecoveryReport) Repaired() bool {
+	return r.Path != ""
+}
+
+// String returns a description of the repair.
+func (r RecoveryReport) String() string {
+	if !r.Repaired() {
+		return "no recovery required"
+	}
+
+	return fmt.Sprintf(
+		"recovered torn record: %s truncated from %d to %d bytes"+
+			" discarding %q",
+		r.Path, r.Size, r.Offset, r.Discarded,
+	)
+}
+
+// Recovery returns the report of any repair made by the last Open.
+func (fs *fileStore) Recovery() RecoveryReport {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	return fs.recovery
+}
+
+// recoverTornRecord truncates a partially written record from the end of
+// the newest data file.
+func (fs *fileStore) recoverTornRecord() {
+	fs.recovery = RecoveryReport{}
+
+	fName := fs.fileHistory[len(fs.fileHistory)-1]
+	if strings.HasSuffix(fName, compressedExtension) {
+		return
+	}
+
+	fPath := fs.filePath(fName)
+
+	report, err := truncateTornRecord(fPath)
+	if err != nil {
+		log.Print("recoverTornRecord(" + fPath + ") failed: " + err.Error())
+
+		return
+	}
+
+	if report.Repaired() {
+		log.Print(report.String())
+	}
+
+	fs.recovery = report
+}
+
+// truncateTornRecord removes any bytes following the last newline in the
+// file.
+func truncateTornRecord(fPath string) (RecoveryReport, error) {
+	var report RecoveryReport
+
+	f, err := os.OpenFile(fPath, os.O_RDWR, 0) //nolint:gosec // Ok.
+	if err != nil {
+		return report, err //nolint:wrapcheck // Ok.
+	}
+
+	defer closeAndLogIfError(f)
+
+	size, offset, err := lastRecordEnd(f)
+	if err != nil || offset == size {
+		return report, err
+	}
+
+	discarded := make([]byte, size-offset)
+
+	_, err = f.ReadAt(discarded, offset)
+	if err == nil {
+		err = f.Truncate(offset)
+	}
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if err == nil {
+		report = RecoveryReport{
+			Path:      fPath,
+			Size:      size,
+			Offset:    offset,
+			Discarded: string(discarded),
+		}
+	}
+
+	return report, err //nolint:wrapcheck // Ok.
+}
+
+// lastRecordEnd returns the size of the file and the offset following its
+// last newline searching backwards from the end one block at a time.
+func lastRecordEnd(f *os.File) (int64, int64, error) {
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return 0, 0, err //nolint:wrapcheck // Ok.
+	}
+
+	size := fileInfo.Size()
+
+	for end := size; end > 0; {
+		start := max(end-tailBlockSize, 0)
+		block := make([]byte, end-start)
+
+		_, err = f.ReadAt(block, start)
+		if err != nil {
+			return 0, 0, err //nolint:wrapcheck // Ok.
+		}
+
+		if i := bytes.LastIndexByte(block, '\n'); i >= 0 {
+			return size, start + int64(i) + 1, nil
+		}
+
+		end = start
+	}
+
+	return size, 0, nil
+}
@@ -0,0 +1,125 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestRecovery_TornRecord(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{ /* clkNano0  */ "", "|U|key1|Complete"},
+		}),
+	)
+
+	fPath := dirName + string(os.PathSeparator) +
+		filename + "_20000515" + fileExtension
+
+	fi, err := os.Stat(fPath)
+	chk.NoErr(err)
+
+	complete := fi.Size()
+
+	const torn = "20000515122458.000000000|U|ke"
+
+	f, err := os.OpenFile( //nolint:gosec // Ok.
+		fPath, os.O_APPEND|os.O_WRONLY, 0,
+	)
+	chk.NoErr(err)
+
+	_, err = f.WriteString(torn)
+	chk.NoErr(err)
+	chk.NoErr(f.Close())
+
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	report := fStore.Recovery()
+	chk.True(report.Repaired())
+	chk.Str(report.Path, fPath)
+	chk.Int64(report.Size, complete+int64(len(torn)))
+	chk.Int64(report.Offset, complete)
+	chk.Str(report.Discarded, torn)
+
+	fi, err = os.Stat(fPath)
+	chk.NoErr(err)
+	chk.Int64(fi.Size(), complete)
+
+	chk.NoErr(fStore.update("key1", "Appended", 0)) // clkNano1
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano2
+		[]string{"{{clkNano0}}", "{{clkNano1}}"},
+		[]string{"Complete", "Appended"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`recovered torn record: {{hPath0}} truncated from `+
+			strconv.FormatInt(complete+int64(len(torn)), base10)+
+			` to `+strconv.FormatInt(complete, base10)+
+			` bytes discarding "`+torn+`"`,
+		`starting path retrieved as: {{hPath0}}`,
+	)
+}
+
+func TestRecovery_NotRequired(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{ /* clkNano0  */ "", "|U|key1|Complete"},
+		}),
+	)
+
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	report := fStore.Recovery()
+	chk.False(report.Repaired())
+	chk.Str(report.String(), "no recovery required")
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{hPath0}}`,
+	)
+}
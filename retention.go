@@ -0,0 +1,172 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+)
+
+// ArchiveFunc is called with the path of each expired data (or threshold
+// journal) file before it is removed.  The file may be copied or moved
+// elsewhere.  Returning an error retains the file until retention is next
+// applied.
+type ArchiveFunc func(string) error
+
+// SetRetentionDays expires data files holding only data older than the
+// provided number of days (the files GetHistoryDays would not read).  A zero
+// (the default) disables the limit.  Retention is applied on Open and
+// whenever a new data file is started.  Threshold journal files are expired
+// once no data file of their period remains.
+func (fs *fileStore) SetRetentionDays(days uint) {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	fs.retentionDays = days
+}
+
+// SetRetentionSize expires the oldest data files until the total size of
+// the remaining files does not exceed maxBytes.  A zero (the default)
+// disables the limit.  The newest file is never expired.
+func (fs *fileStore) SetRetentionSize(maxBytes int64) {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	fs.retentionSize = max(maxBytes, 0)
+}
+
+// SetArchiveFunc registers a function to archive expired data and threshold
+// journal files before they are removed.
+func (fs *fileStore) SetArchiveFunc(archive ArchiveFunc) {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	fs.archive = archive
+}
+
+func (fs *fileStore) retentionSet() bool {
+	return fs.retentionDays > 0 || fs.retentionSize > 0
+}
+
+// applyRetention expires old data files removing them from the file history.
+func (fs *fileStore) applyRetention(now time.Time) {
+	if !fs.retentionSet() || len(fs.fileHistory) < 2 {
+		return
+	}
+
+	minStamp := ""
+	if fs.retentionDays > 0 {
+		minStamp = fs.daysStamp(now, fs.retentionDays)
+	}
+
+	newest := len(fs.fileHistory) - 1
+	expired := make([]bool, len(fs.fileHistory))
+	totalSize := int64(0)
+	sizeExceeded := false
+
+	for i := newest; i >= 0; i-- {
+		fName := fs.fileHistory[i]
+
+		if i != newest {
+			if sizeExceeded || !fs.fileInRange(fName, minStamp, "") {
+				expired[i] = true
+
+				continue
+			}
+		}
+
+		if fs.retentionSize > 0 {
+			fileInfo, err := os.Stat(fs.filePath(fName))
+			if err == nil {
+				totalSize += fileInfo.Size()
+			}
+
+			if i != newest && totalSize > fs.retentionSize {
+				expired[i] = true
+				sizeExceeded = true
+			}
+		}
+	}
+
+	retained := fs.fileHistory[:0]
+
+	for i, fName := range fs.fileHistory {
+		if !expired[i] || !fs.expireFile(fName, "data") {
+			retained = append(retained, fName)
+		}
+	}
+
+	fs.fileHistory = retained
+
+	fs.expireJournals()
+}
+
+// expireJournals expires the threshold journal files older than the oldest
+// retained data file removing them from the journal history.  The journal
+// file being written is never expired.
+func (fs *fileStore) expireJournals() {
+	if len(fs.fileHistory) == 0 {
+		return
+	}
+
+	oldest, _, ok := fs.splitFileName(fs.fileHistory[0])
+	if !ok {
+		return
+	}
+
+	retained := fs.journalHistory[:0]
+
+	for _, fName := range fs.journalHistory {
+		stamp := fs.journalStamp(fName)
+		if stamp >= oldest || stamp == fs.journalFileStamp ||
+			!fs.expireFile(fName, "journal") {
+			retained = append(retained, fName)
+		}
+	}
+
+	fs.journalHistory = retained
+}
+
+// expireFile archives (if requested) and removes the data or journal file
+// returning true if it no longer exists.
+func (fs *fileStore) expireFile(fName, kind string) bool {
+	fPath := fs.filePath(fName)
+
+	if fs.archive != nil {
+		err := fs.archive(fPath)
+		if err != nil {
+			log.Print("archive(" + fPath + ") failed: " + err.Error())
+
+			return false
+		}
+	}
+
+	err := os.Remove(fPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Print("expire(" + fPath + ") failed: " + err.Error())
+
+		return false
+	}
+
+	log.Print("expired " + kind + " file: " + fPath)
+
+	return true
+}
@@ -0,0 +1,186 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+var errTestArchive = errors.New("archive unavailable")
+
+func TestRetention_DaysOnOpen(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	for daysAgo := 3; daysAgo >= 0; daysAgo-- {
+		chk.NoErr(
+			buildHistoryFile(chk, daysAgo, dirName, filename, [][2]string{
+				{"", "|U|key1|v"},
+			}),
+		)
+	}
+
+	var archived []string
+
+	fStore.SetRetentionDays(1)
+	fStore.SetArchiveFunc(func(fPath string) error {
+		archived = append(archived, fPath)
+		if fPath == filepath.Join(dirName, filename+"_20000513.dat") {
+			return errTestArchive
+		}
+
+		return nil
+	})
+
+	chk.NoErr(fStore.Open())
+	defer closeAndLogIfError(fStore)
+
+	chk.StrSlice(archived, []string{"{{hPath3}}", "{{hPath2}}"})
+	chk.Int(countFiles(dirName, filename), 3)
+	chk.StrSlice(
+		fStore.fileHistory,
+		[]string{
+			filename + "_20000513" + fileExtension,
+			filename + "_20000514" + fileExtension,
+			filename + "_20000515" + fileExtension,
+		},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`expired data file: {{hPath3}}`,
+		`archive({{hPath2}}) failed: archive unavailable`,
+		`starting path retrieved as: {{hPath0}}`,
+	)
+}
+
+func TestRetention_SizeOnRollover(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	headerSize := int64(len(fileHeader()))
+	recordSize := int64(len(`20000515122456.000000000|U|key1|"v1"` + "\n"))
+
+	chk.NoErr(fStore.SetMaxFileSize(headerSize + recordSize))
+	// The new (header only) file plus two full files.
+	fStore.SetRetentionSize(headerSize + 2*(headerSize+recordSize))
+
+	chk.NoErr(fStore.Open()) // clkNano0
+
+	for _, v := range []string{"v1", "v2", "v3", "v4"} {
+		chk.NoErr(fStore.update("key1", v, 0)) // clkNano1 - clkNano4
+	}
+
+	// Retention is applied as each new (empty) file is started.
+	chk.Int(countFiles(dirName, filename), 3)
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano5
+		[]string{"{{clkNano2}}", "{{clkNano3}}", "{{clkNano4}}"},
+		[]string{"v2", "v3", "v4"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`expired data file: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestRetention_JournalOnOpen(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	for daysAgo := 3; daysAgo >= 0; daysAgo-- {
+		chk.NoErr(
+			buildHistoryFile(chk, daysAgo, dirName, filename, [][2]string{
+				{"", "|U|key1|v"},
+			}),
+		)
+	}
+
+	for _, stamp := range []string{"20000511", "20000512", "20000514"} {
+		chk.NoErr(
+			os.WriteFile(
+				filepath.Join(dirName, filename+"_"+stamp+journalExtension),
+				nil,
+				defaultFilePermissions,
+			),
+		)
+	}
+
+	var archived []string
+
+	chk.NoErr(fStore.SetThresholdJournal(true))
+	fStore.SetRetentionDays(2)
+	fStore.SetArchiveFunc(func(fPath string) error {
+		archived = append(archived, filepath.Base(fPath))
+
+		return nil
+	})
+
+	chk.NoErr(fStore.Open())
+	defer closeAndLogIfError(fStore)
+
+	// Journals are kept while a data file of their period remains.
+	chk.StrSlice(
+		archived,
+		[]string{
+			filename + "_20000512" + fileExtension,
+			filename + "_20000511" + journalExtension,
+			filename + "_20000512" + journalExtension,
+		},
+	)
+	chk.StrSlice(
+		fStore.journalHistory,
+		[]string{filename + "_20000514" + journalExtension},
+	)
+	chk.Int(countFiles(dirName, filename), 4)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`expired data file: {{hPath3}}`,
+		`starting path retrieved as: {{hPath0}}`,
+		`expired journal file: {{dir}}/{{file}}_20000511.thr`,
+		`expired journal file: {{dir}}/{{file}}_20000512.thr`,
+	)
+}
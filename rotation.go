@@ -0,0 +1,106 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"time"
+)
+
+const (
+	fmtHourStamp  = "2006010215"
+	fmtWeekStamp  = "20060102W"
+	fmtSequence   = "%06d"
+	daysPerWeek   = 7
+	sequenceWidth = 6
+)
+
+// Rotation indicates the period covered by each data file.
+type Rotation byte
+
+// Rotation constants.
+const (
+	RotateDaily  Rotation = 'D'
+	RotateHourly Rotation = 'H'
+	RotateWeekly Rotation = 'W'
+)
+
+func (r Rotation) String() string {
+	switch r {
+	case RotateDaily:
+		return "D - Daily"
+	case RotateHourly:
+		return "H - Hourly"
+	case RotateWeekly:
+		return "W - Weekly"
+	default:
+		return "? - ROTATION(" + string(r) + ")"
+	}
+}
+
+// IsOK checks that the rotation is valid.
+func (r Rotation) IsOK() bool {
+	return r == RotateDaily || r == RotateHourly || r == RotateWeekly
+}
+
+// format returns the layout used to stamp file names.  Weekly files are
+// stamped with the date of the Monday starting the week followed by a "W"
+// keeping them apart from daily files.
+func (r Rotation) format() string {
+	switch r {
+	case RotateHourly:
+		return fmtHourStamp
+	case RotateWeekly:
+		return fmtWeekStamp
+	default:
+		return fmtDateStamp
+	}
+}
+
+// validStamp reports if the stamp names the start of one of the rotation's
+// periods.
+func (r Rotation) validStamp(stamp string) bool {
+	if len(stamp) != len(r.format()) {
+		return false
+	}
+
+	t, err := time.Parse(r.format(), stamp)
+
+	return err == nil && r.start(t).Equal(t)
+}
+
+// start returns the beginning of the period containing t.
+func (r Rotation) start(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	switch r {
+	case RotateHourly:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	case RotateWeekly:
+		day -= (int(t.Weekday()) + daysPerWeek - 1) % daysPerWeek
+
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// stamp returns the file name stamp for the period containing t.
+func (r Rotation) stamp(t time.Time) string {
+	return r.start(t).Format(r.format())
+}
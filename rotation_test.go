@@ -0,0 +1,283 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestImpl_Rotation(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	badRotation := Rotation('B')
+
+	chk.True(RotateDaily.IsOK())
+	chk.True(RotateHourly.IsOK())
+	chk.True(RotateWeekly.IsOK())
+	chk.False(badRotation.IsOK())
+
+	chk.Str(RotateDaily.String(), "D - Daily")
+	chk.Str(RotateHourly.String(), "H - Hourly")
+	chk.Str(RotateWeekly.String(), "W - Weekly")
+	chk.Str(badRotation.String(), "? - ROTATION(B)")
+
+	// Monday 2000-05-15.
+	for _, ts := range []time.Time{
+		time.Date(2000, 5, 15, 0, 0, 0, 0, time.Local),
+		time.Date(2000, 5, 17, 12, 24, 56, 0, time.Local),
+		time.Date(2000, 5, 21, 23, 59, 59, 999999999, time.Local),
+	} {
+		chk.Str(RotateWeekly.stamp(ts), "20000515W")
+	}
+
+	chk.Str(
+		RotateWeekly.stamp(time.Date(2000, 5, 14, 23, 0, 0, 0, time.Local)),
+		"20000508W",
+	)
+
+	// Stamps must name the start of a period of the rotation.
+	chk.True(RotateWeekly.validStamp("20000515W"))
+	chk.False(RotateWeekly.validStamp("20000516W")) // Tuesday.
+	chk.False(RotateWeekly.validStamp("20000515"))
+	chk.False(RotateDaily.validStamp("20000515W"))
+	chk.True(RotateDaily.validStamp("20000515"))
+	chk.False(RotateHourly.validStamp("20000515"))
+
+	chk.Str(
+		RotateHourly.stamp(time.Date(2000, 5, 14, 23, 1, 0, 0, time.Local)),
+		"2000051423",
+	)
+	chk.Str(
+		RotateDaily.stamp(time.Date(2000, 5, 14, 23, 1, 0, 0, time.Local)),
+		"20000514",
+	)
+}
+
+func TestRotation_InvalidSettings(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.Err(
+		fStore.SetRotation(Rotation('B')),
+		ErrInvalidRotation.Error(),
+	)
+
+	chk.NoErr(fStore.Open())
+	defer closeAndLogIfError(fStore)
+
+	chk.Err(
+		fStore.SetRotation(RotateHourly),
+		ErrOpenedRotation.Error(),
+	)
+
+	chk.Err(
+		fStore.SetMaxFileSize(100),
+		ErrOpenedRotation.Error(),
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestRotation_Hourly(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 59, 58, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.SetRotation(RotateHourly))
+
+	// A daily file is ignored.
+	chk.NoErr(
+		os.WriteFile(
+			filepath.Join(dirName, filename+"_20000515"+fileExtension),
+			[]byte("20000515125957.000000000|U|key1|ignored\n"),
+			0o0600,
+		),
+	)
+
+	chk.NoErr(fStore.Open())                     // clkNano0 12:59:58
+	chk.NoErr(fStore.update("key1", "one", 1))   // clkNano1 12:59:59
+	chk.NoErr(fStore.update("key1", "two", 2))   // clkNano2 13:00:00
+	chk.NoErr(fStore.update("key1", "three", 3)) // clkNano3 13:00:01
+
+	chk.NoErr(fStore.Close())
+
+	// Reopen to catalog and reload the hourly files.
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	chk.NoErr(fStore.SetRotation(RotateHourly))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.StrSlice(
+		fStore.fileHistory,
+		[]string{
+			filename + "_2000051512" + fileExtension,
+			filename + "_2000051513" + fileExtension,
+		},
+	)
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano4
+		[]string{"{{clkNano1}}", "{{clkNano2}}", "{{clkNano3}}"},
+		[]string{"one", "two", "three"},
+	)
+
+	validateHistoryRange(chk, fStore, "key1",
+		time.Date(2000, 5, 15, 13, 0, 0, 0, time.Local),
+		time.Date(2000, 5, 15, 13, 59, 0, 0, time.Local),
+		[]string{"{{clkNano2}}", "{{clkNano3}}"},
+		[]string{"two", "three"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_2000051512.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{dir}}/{{file}}_2000051513.dat`,
+	)
+}
+
+func TestRotation_Weekly(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 20, 12, 0, 0, 0, time.Local), // Saturday.
+		time.Hour*24,
+	)
+
+	chk.NoErr(fStore.SetRotation(RotateWeekly))
+
+	// A daily file is ignored.
+	chk.NoErr(
+		os.WriteFile(
+			filepath.Join(dirName, filename+"_20000515"+fileExtension),
+			[]byte("20000515125957.000000000|U|key1|ignored\n"),
+			0o0600,
+		),
+	)
+
+	chk.NoErr(fStore.Open())                   // clkNano0 Saturday
+	chk.NoErr(fStore.update("key1", "sun", 1)) // clkNano1 Sunday
+	chk.NoErr(fStore.update("key1", "mon", 2)) // clkNano2 Monday
+	chk.NoErr(fStore.update("key1", "tue", 3)) // clkNano3 Tuesday
+
+	chk.Int(countFiles(dirName, filename), 3)
+	chk.StrSlice(
+		fStore.fileHistory,
+		[]string{
+			filename + "_20000515W" + fileExtension,
+			filename + "_20000522W" + fileExtension,
+		},
+	)
+
+	validateHistory(chk, fStore, "key1", 2, // clkNano4 Wednesday
+		[]string{"{{clkNano2}}", "{{clkNano3}}"},
+		[]string{"mon", "tue"},
+	)
+
+	validateHistory(chk, fStore, "key1", 4, // clkNano5 Thursday
+		[]string{"{{clkNano1}}", "{{clkNano2}}", "{{clkNano3}}"},
+		[]string{"sun", "mon", "tue"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515W.dat`,
+	)
+}
+
+func TestRotation_MaxFileSize(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	maxSize := int64(len(fileHeader())) +
+		2*int64(len(`20000515122456.000000000|U|key1|"v1"`+"\n"))
+
+	chk.NoErr(fStore.SetMaxFileSize(maxSize))
+	chk.NoErr(fStore.Open()) // clkNano0
+
+	for _, v := range []string{"v1", "v2", "v3", "v4", "v5"} {
+		chk.NoErr(fStore.update("key1", v, 0)) // clkNano1 - clkNano5
+	}
+
+	chk.NoErr(fStore.Close())
+
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	chk.NoErr(fStore.SetMaxFileSize(maxSize))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	chk.StrSlice(
+		fStore.fileHistory,
+		[]string{
+			filename + "_20000515" + fileExtension,
+			filename + "_20000515_000001" + fileExtension,
+			filename + "_20000515_000002" + fileExtension,
+		},
+	)
+
+	chk.NoErr(fStore.update("key1", "v6", 0)) // clkNano6
+
+	chk.Int(countFiles(dirName, filename), 3)
+
+	validateHistory(chk, fStore, "key1", 0, // clkNano7
+		[]string{
+			"{{clkNano1}}", "{{clkNano2}}", "{{clkNano3}}",
+			"{{clkNano4}}", "{{clkNano5}}", "{{clkNano6}}",
+		},
+		[]string{"v1", "v2", "v3", "v4", "v5", "v6"},
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515_000002.dat`,
+	)
+}
@@ -0,0 +1,146 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+const (
+	staleChecksPerSilence = 4
+	minWatchInterval      = time.Millisecond
+)
+
+// StaleNotifyFunc defines the stale threshold callback function.  It is
+// invoked with stale set when no update has been received for the key
+// within the threshold's maximum silence and again with stale cleared when
+// the next update is received.
+type StaleNotifyFunc func(
+	datKey string,
+	stale bool,
+	lastUpdate time.Time, // The last (or recovering) update.
+)
+
+// staleThreshold monitors the time since a key was last updated.
+type staleThreshold struct {
+	datKey     string
+	maxSilence time.Duration
+	callback   StaleNotifyFunc
+	lastUpdate time.Time
+	stale      bool
+}
+
+// AddStaleThreshold monitors the specified key reporting when no update has
+// been received within maxSilence and when updates resume.  The time since
+// the last update (or since the store was opened if the key has never been
+// updated) is checked periodically by a background ticker against the store
+// clock.
+func (fs *fileStore) AddStaleThreshold(
+	datKey string, maxSilence time.Duration, notifyFunc StaleNotifyFunc,
+) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if maxSilence <= 0 {
+		return ErrInvalidMaxSilence
+	}
+
+	if notifyFunc == nil {
+		return ErrNilNotifyFunc
+	}
+
+	if fs.dispatcher != nil {
+		notifyFunc = fs.dispatcher.wrapStale(notifyFunc)
+	}
+
+	st := &staleThreshold{
+		datKey:     datKey,
+		maxSilence: maxSilence,
+		callback:   notifyFunc,
+	}
+	fs.stale[datKey] = append(fs.stale[datKey], st)
+
+	if fs.opened {
+		fs.startStale(fs.ts())
+	}
+
+	return nil
+}
+
+// startStale sets the starting time of any new stale thresholds to their
+// key's last update (or now) and starts (or speeds up) the background
+// ticker checking them.
+func (fs *fileStore) startStale(now time.Time) {
+	for datKey, sts := range fs.stale {
+		for _, st := range sts {
+			if st.lastUpdate.IsZero() {
+				st.lastUpdate = now
+				if data, ok := fs.data[datKey]; ok {
+					st.lastUpdate = data.TS
+				}
+			}
+		}
+	}
+
+	fs.startWatch()
+}
+
+// checkStale reports any keys that have become stale.
+func (fs *fileStore) checkStale() {
+	defer fs.dispatch()
+
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if len(fs.stale) == 0 {
+		return
+	}
+
+	now := fs.ts()
+
+	for _, datKey := range slices.Sorted(maps.Keys(fs.stale)) {
+		for _, st := range fs.stale[datKey] {
+			if !st.stale && now.Sub(st.lastUpdate) > st.maxSilence {
+				st.stale = true
+				callback, lastUpdate := st.callback, st.lastUpdate
+				fs.stage(func() {
+					callback(datKey, true, lastUpdate)
+				})
+			}
+		}
+	}
+}
+
+// recoverStale records an update to the key staging the recovery of any of
+// its stale thresholds.
+func (fs *fileStore) recoverStale(datKey string, timestamp time.Time) {
+	for _, st := range fs.stale[datKey] {
+		st.lastUpdate = timestamp
+
+		if st.stale {
+			st.stale = false
+			callback := st.callback
+			fs.stage(func() {
+				callback(datKey, false, timestamp)
+			})
+		}
+	}
+}
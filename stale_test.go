@@ -0,0 +1,180 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestStale_Check(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 0, 0, 0, time.Local),
+	)
+
+	now := time.Date(2000, 5, 15, 12, 0, 0, 0, time.Local)
+	fStore.ts = func() time.Time {
+		return now
+	}
+
+	notify := func(datKey string, stale bool, lastUpdate time.Time) {
+		log.Printf("Stale(%q): %t last update: %s",
+			datKey, stale, lastUpdate.Format(time.TimeOnly),
+		)
+	}
+
+	chk.Err(
+		fStore.AddStaleThreshold("key1", 0, notify),
+		ErrInvalidMaxSilence.Error(),
+	)
+	chk.Err(
+		fStore.AddStaleThreshold("key1", time.Minute, nil),
+		ErrNilNotifyFunc.Error(),
+	)
+	chk.NoErr(fStore.AddStaleThreshold("key1", time.Minute*10, notify))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	now = now.Add(time.Minute * 5)
+	fStore.checkStale()
+	chk.NoErr(fStore.update("key1", "v", 1))
+
+	now = now.Add(time.Minute * 11)
+	fStore.checkStale()
+	fStore.checkStale() // Only reported once.
+
+	now = now.Add(time.Minute)
+	chk.NoErr(fStore.update("key1", "v", 2))
+
+	// Added to an opened store for a key never updated.
+	chk.NoErr(fStore.AddStaleThreshold("key2", time.Minute, notify))
+
+	now = now.Add(time.Minute * 2)
+	fStore.checkStale()
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Stale("key1"): true last update: 12:05:00`,
+		`Stale("key1"): false last update: 12:17:00`,
+		`Stale("key2"): true last update: 12:17:00`,
+	)
+}
+
+func TestStale_Ticker(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 0, 0, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.SetNotifyDispatcher(1, DispatchBlock))
+
+	reported := make(chan bool, 4)
+
+	chk.NoErr(
+		fStore.AddStaleThreshold("key1", time.Millisecond*1500,
+			func(_ string, stale bool, _ time.Time) {
+				reported <- stale
+			},
+		),
+	)
+	chk.NoErr(fStore.Open())
+
+	// Each check advances the test clock a second.
+	select {
+	case stale := <-reported:
+		chk.True(stale)
+	case <-time.After(time.Second * 5):
+		t.Error("stale threshold not reported")
+	}
+
+	chk.NoErr(fStore.update("key1", "v", 1))
+	chk.NoErr(fStore.Close())
+	chk.False(<-reported)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestStale_RecoveryReads(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 0, 0, 0, time.Local),
+	)
+
+	now := time.Date(2000, 5, 15, 12, 0, 0, 0, time.Local)
+	fStore.ts = func() time.Time {
+		return now
+	}
+
+	// The callbacks read the store after it has been released.
+	chk.NoErr(
+		fStore.AddStaleThreshold("key1", time.Minute,
+			func(datKey string, stale bool, _ time.Time) {
+				_, value, ok := fStore.get(datKey)
+				log.Printf("Stale(%q): %t value: %q %t",
+					datKey, stale, value, ok,
+				)
+			},
+		),
+	)
+	chk.NoErr(fStore.Open())
+
+	now = now.Add(time.Minute * 2)
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		fStore.checkStale()
+		chk.NoErr(fStore.update("key1", "v", 1))
+		chk.NoErr(fStore.Close())
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second * 5):
+		t.Fatal("stale callback deadlocked")
+	}
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`get("key1"): unknown data key`,
+		`Stale("key1"): true value: "" false`,
+		`Stale("key1"): false value: "v" true`,
+	)
+}
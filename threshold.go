@@ -72,13 +72,19 @@ func (r ThresholdReason) String() string {
 type threshold struct {
 	datKey        string
 	winKey        string
+	id            string // Identifies the threshold in the journal.
 	lowCritical   float64
 	lowWarning    float64
 	highWarning   float64
 	highCritical  float64
+	deadband      float64
+	dwell         time.Duration
 	currentReason ThresholdReason
+	pendingReason ThresholdReason
 	callback      ThresholdNotifyFunc
-	started       time.Time
+	journal       func(from, to ThresholdReason, value float64)
+	started       time.Time // When the pending (or current) level began.
+	since         time.Time // When the current level began.
 }
 
 // New returns a new Thresholds Data structure.
@@ -87,12 +93,11 @@ func newThreshold(
 	lowCritical, lowWarning, highWarning, highCritical float64,
 	notifyFunc ThresholdNotifyFunc,
 ) (*threshold, error) {
-	invalid := false ||
-		lowCritical > lowWarning ||
-		lowWarning > highWarning ||
-		highWarning > highCritical
-	if invalid {
-		return nil, ErrInvalidThresholdOrder
+	err := checkThresholdOrder(
+		lowCritical, lowWarning, highWarning, highCritical,
+	)
+	if err != nil {
+		return nil, err
 	}
 
 	if notifyFunc == nil {
@@ -108,13 +113,53 @@ func newThreshold(
 		highCritical:  highCritical,
 		callback:      notifyFunc,
 		currentReason: ThresholdUnknown,
-		started:       time.Now(),
+		pendingReason: ThresholdUnknown,
 	}, nil
 }
 
+// checkThresholdOrder verifies the limits do not overlap.
+func checkThresholdOrder(
+	lowCritical, lowWarning, highWarning, highCritical float64,
+) error {
+	invalid := false ||
+		lowCritical > lowWarning ||
+		lowWarning > highWarning ||
+		highWarning > highCritical
+	if invalid {
+		return ErrInvalidThresholdOrder
+	}
+
+	return nil
+}
+
+// setLimits replaces the threshold's limits retaining its current reason.
+func (d *threshold) setLimits(
+	lowCritical, lowWarning, highWarning, highCritical float64,
+) error {
+	err := checkThresholdOrder(
+		lowCritical, lowWarning, highWarning, highCritical,
+	)
+	if err == nil {
+		d.lowCritical = lowCritical
+		d.lowWarning = lowWarning
+		d.highWarning = highWarning
+		d.highCritical = highCritical
+	}
+
+	return err
+}
+
 // Check determines if the threshold has changed and if so invokes the supplied
-// callback function.
-func (d *threshold) check(value float64) {
+// callback function.  With a dwell time the new level is held pending until
+// a sample at least the dwell after it began is still within it.  The
+// timestamp of the sample beginning the new level is recorded as its start.
+func (d *threshold) check(timestamp time.Time, value float64) {
+	if d.holds(value) {
+		d.pendingReason = ThresholdUnknown
+
+		return
+	}
+
 	var newReason ThresholdReason
 
 	switch {
@@ -130,9 +175,52 @@ func (d *threshold) check(value float64) {
 		newReason = ThresholdHighCritical
 	}
 
-	if d.currentReason != newReason {
-		oldReason := d.currentReason
-		d.currentReason = newReason
-		d.callback(d.datKey, d.winKey, oldReason, newReason, value)
+	if d.currentReason == newReason {
+		d.pendingReason = ThresholdUnknown
+
+		return
+	}
+
+	if d.pendingReason != newReason {
+		d.pendingReason = newReason
+		d.started = timestamp
+	}
+
+	if timestamp.Sub(d.started) < d.dwell {
+		return
+	}
+
+	oldReason := d.currentReason
+	d.currentReason = newReason
+	d.pendingReason = ThresholdUnknown
+	d.since = d.started
+	d.callback(d.datKey, d.winKey, oldReason, newReason, value)
+
+	if d.journal != nil {
+		d.journal(oldReason, newReason, value)
+	}
+}
+
+// holds reports if the value remains within the current level's band
+// widened by the deadband on the side of the less severe level.  A more
+// severe level is entered at its configured boundary.
+func (d *threshold) holds(value float64) bool {
+	if d.deadband <= 0 {
+		return false
+	}
+
+	switch d.currentReason {
+	case ThresholdLowCritical:
+		return value <= d.lowCritical+d.deadband
+	case ThresholdLowWarning:
+		return value > d.lowCritical &&
+			value <= d.lowWarning+d.deadband
+	case ThresholdHighWarning:
+		return value >= d.highWarning-d.deadband &&
+			value < d.highCritical
+	case ThresholdHighCritical:
+		return value >= d.highCritical-d.deadband
+	default:
+		return false
 	}
 }
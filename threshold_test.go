@@ -20,7 +20,9 @@ package szstore
 
 import (
 	"log"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/dancsecs/sztest"
 )
@@ -93,15 +95,19 @@ func TestThresholdData_NotifyFunction(t *testing.T) {
 	)
 	chk.NoErr(err)
 
+	ts := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
 	var value float64
 	for value = 0.0; value < 26.0; value++ {
-		threshold.check(value)
+		threshold.check(ts, value)
 	}
 
 	for value = 25.0; value >= 0.0; value-- {
-		threshold.check(value)
+		threshold.check(ts.Add(time.Second), value)
 	}
 
+	chk.True(threshold.started.Equal(ts.Add(time.Second)))
+
 	chk.AddSub(`4\.000000`, "3.000000")
 	chk.AddSub(`2\.000000`, "3.000000")
 	chk.AddSub(`1\.000000`, "3.000000")
@@ -118,3 +124,149 @@ func TestThresholdData_NotifyFunction(t *testing.T) {
 		"Threshold for Key: datKey Window: winKey changed from: Low Warning          to: Low Critical         for value: 5.000000",
 	)
 }
+
+func TestThresholdData_Deadband(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	const deadband = 2
+
+	levels := []ThresholdReason{
+		ThresholdLowCritical,
+		ThresholdLowWarning,
+		ThresholdNormal,
+		ThresholdHighWarning,
+		ThresholdHighCritical,
+	}
+	inside := []float64{5, 15, 25, 35, 45}
+	// Boundaries below and above each level.
+	lower := []float64{0, 10, 20, 30, 40}
+	upper := []float64{10, 20, 30, 40, 0}
+
+	ts := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
+	for from := range levels {
+		for to := range levels {
+			if from == to {
+				continue
+			}
+
+			var reasons []byte
+
+			threshold, err := newThreshold(
+				"datKey", "winKey", 10, 20, 30, 40,
+				func(_, _ string, _, n ThresholdReason, _ float64) {
+					reasons = append(reasons, byte(n))
+				},
+			)
+			chk.NoErr(err)
+
+			threshold.deadband = deadband
+			threshold.check(ts, inside[from])
+
+			// Crossing the boundary toward a less severe level by less than
+			// the deadband holds the level while a more severe level is
+			// entered immediately.
+			near, next := upper[from]+deadband/2, from+1
+			if to < from {
+				near, next = lower[from]-deadband/2, from-1
+			}
+
+			threshold.check(ts, near)
+			threshold.check(ts, inside[to])
+
+			expected := []byte{byte(levels[from])}
+
+			normal := slices.Index(levels, ThresholdNormal)
+			if (from < normal && to > from) || (from > normal && to < from) {
+				expected = append(expected, byte(levels[to]))
+			} else {
+				expected = append(expected, byte(levels[next]))
+				if next != to {
+					expected = append(expected, byte(levels[to]))
+				}
+			}
+
+			chk.Str(string(reasons), string(expected))
+		}
+	}
+}
+
+func TestThresholdData_DeadbandBoundaries(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	var reasons []byte
+
+	threshold, err := newThreshold(
+		"datKey", "winKey", 10, 20, 30, 40,
+		func(_, _ string, _, n ThresholdReason, _ float64) {
+			reasons = append(reasons, byte(n))
+		},
+	)
+	chk.NoErr(err)
+
+	threshold.deadband = 2
+
+	ts := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
+	for _, value := range []float64{
+		25, // Normal.
+		30, // High warning entered at its boundary.
+		29, // Held.
+		28, // Held (high warning extends below 30 by 2).
+		27, // Normal.
+		20, // Low warning entered at its inclusive boundary.
+		22, // Low warning held (inclusive boundary plus 2).
+		23, // Normal.
+		40, // High critical entered at its boundary.
+		38, // Held.
+		37, // High warning.
+		39, // High warning (critical not yet reached).
+	} {
+		threshold.check(ts, value)
+	}
+
+	chk.Str(string(reasons), "NWNwNCW")
+}
+
+func TestThresholdData_Dwell(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	var reasons []byte
+
+	threshold, err := newThreshold(
+		"datKey", "winKey", 10, 20, 30, 40,
+		func(_, _ string, _, n ThresholdReason, _ float64) {
+			reasons = append(reasons, byte(n))
+		},
+	)
+	chk.NoErr(err)
+
+	threshold.dwell = time.Second * 10
+
+	ts := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
+	for _, tst := range []struct {
+		offset  time.Duration
+		value   float64
+		pending ThresholdReason
+	}{
+		{0, 25, ThresholdNormal},
+		{time.Second * 5, 26, ThresholdNormal},
+		{time.Second * 10, 27, ThresholdUnknown}, // Normal reported.
+		{time.Second * 11, 35, ThresholdHighWarning},
+		{time.Second * 15, 25, ThresholdUnknown}, // Back to normal.
+		{time.Second * 16, 36, ThresholdHighWarning},
+		{time.Second * 20, 45, ThresholdHighCritical}, // Restarted.
+		{time.Second * 29, 44, ThresholdHighCritical},
+		{time.Second * 30, 44, ThresholdUnknown}, // High critical reported.
+	} {
+		threshold.check(ts.Add(tst.offset), tst.value)
+		chk.Str(threshold.pendingReason.String(), tst.pending.String())
+	}
+
+	chk.Str(string(reasons), "NC")
+	chk.True(threshold.since.Equal(ts.Add(time.Second * 20)))
+}
@@ -0,0 +1,99 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"time"
+)
+
+// startWatch starts (or speeds up) the background ticker checking the stale
+// thresholds and tumbling windows against the store clock.
+func (fs *fileStore) startWatch() {
+	interval := time.Duration(0)
+
+	faster := func(check time.Duration) {
+		check = max(check, minWatchInterval)
+		if interval == 0 || check < interval {
+			interval = check
+		}
+	}
+
+	for _, sts := range fs.stale {
+		for _, st := range sts {
+			faster(st.maxSilence / staleChecksPerSilence)
+		}
+	}
+
+	for _, wdb := range fs.winDB {
+		for _, w := range wdb.windows {
+			if w.kind == windowTumbling {
+				faster(w.period / bucketChecksPerPeriod)
+			}
+		}
+	}
+
+	switch {
+	case interval == 0:
+	case fs.watchTicker == nil:
+		fs.watchTicker = time.NewTicker(interval)
+		fs.watchStop = make(chan struct{})
+		fs.watchInterval = interval
+
+		fs.watching.Add(1)
+
+		go fs.watch(fs.watchTicker, fs.watchStop)
+	case interval < fs.watchInterval:
+		fs.watchTicker.Reset(interval)
+		fs.watchInterval = interval
+	}
+}
+
+// stopWatch stops the background ticker waiting for any check in progress.
+// It must be called without holding the store lock.
+func (fs *fileStore) stopWatch() {
+	fs.rwMutex.Lock()
+	stop := fs.watchStop
+
+	if fs.watchTicker != nil {
+		fs.watchTicker.Stop()
+	}
+
+	fs.watchTicker = nil
+	fs.watchStop = nil
+	fs.rwMutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		fs.watching.Wait()
+	}
+}
+
+func (fs *fileStore) watch(ticker *time.Ticker, stop <-chan struct{}) {
+	defer fs.watching.Done()
+
+	for {
+		select {
+		case <-ticker.C:
+			fs.checkStale()
+			fs.checkBuckets()
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+// AddCountWindow creates a named window for the specified key holding the
+// provided number of most recent samples regardless of their age.  A window
+// added to an opened store is back-filled like those added by AddWindow.
+func (fs *fileStore) AddCountWindow(
+	datKey, winKey string, samples uint,
+) error {
+	return fs.addKeyWindow(datKey, winKey, func(wdb *winDB) error {
+		return wdb.addCountWindow(winKey, samples)
+	})
+}
+
+// addCountWindow includes a new window of the most recent samples.  The
+// shared entry list retains at least as many entries as the largest.
+func (wdb *winDB) addCountWindow(winKey string, samples uint) error {
+	newWin := newWindow(wdb.datKey, winKey, 0)
+	newWin.kind = windowCount
+	newWin.samples = uint64(max(samples, 1))
+
+	err := wdb.register(newWin)
+	if err == nil && newWin.samples > wdb.maxSamples {
+		wdb.maxSamples = newWin.samples
+	}
+
+	return err
+}
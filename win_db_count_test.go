@@ -0,0 +1,150 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestWindowWindows_Count(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	ts := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
+	wdb := newWinDB("datKey1")
+	chk.NoErr(wdb.addCountWindow("last3", 3))
+	chk.NoErr(wdb.addWindow("sliding", time.Second))
+	chk.Err(wdb.addCountWindow("last3", 5), ErrDupWinKey.Error())
+
+	var reasons []byte
+
+	chk.NoErr(
+		wdb.addThreshold("last3", 1, 2, 8, 9,
+			func(_, _ string, _, to ThresholdReason, _ float64) {
+				reasons = append(reasons, byte(to))
+			},
+		),
+	)
+
+	_, err := wdb.getAvg("last3")
+	chk.Err(err, ErrNoWinData.Error())
+
+	for _, tst := range []struct {
+		gap        time.Duration
+		value      float64
+		avg        float64
+		count      uint64
+		numEntries int
+	}{
+		{0, 3, 3, 1, 1},
+		{time.Second, 6, 4.5, 2, 2},
+		{time.Second, 9, 6, 3, 3},
+		{time.Second, 12, 9, 3, 3},
+		{time.Hour, 0, 7, 3, 3}, // Age does not matter.
+		{time.Hour, 0, 4, 3, 3},
+		{time.Hour, 0, 0, 3, 3},
+	} {
+		ts = ts.Add(tst.gap)
+		wdb.addValue(ts, tst.value)
+
+		got, err := wdb.getAvg("last3")
+		chk.NoErr(err)
+		chk.Float64(got, tst.avg, 0)
+
+		count, err := wdb.getCount("last3")
+		chk.NoErr(err)
+		chk.Uint64(count, tst.count)
+
+		chk.Int(wdb.count(), tst.numEntries)
+	}
+
+	got, err := wdb.getStat("last3", (*window).getMax)
+	chk.NoErr(err)
+	chk.Float64(got, 0, 0)
+
+	count, err := wdb.getCount("sliding")
+	chk.NoErr(err)
+	chk.Uint64(count, 1)
+
+	// Normal, high critical, normal, low critical.
+	chk.Str(string(reasons), "NCNc")
+
+	chk.Err(
+		wdb.setAverageMode("last3", AverageTime), ErrWindowKind.Error(),
+	)
+}
+
+func TestWindowWindows_AddCountWindow(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	setupFloatValues(fStore)
+
+	chk.NoErr(fStore.AddCountWindow("key1", "last2", 2))
+	chk.NoErr(fStore.AddCountWindow("key1", "last1", 0)) // At least one.
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	for _, v := range []float64{1, 2, 3, 4} {
+		chk.NoErr(
+			fStore.update("key1", strconv.FormatFloat(v, 'g', -1, 64), v),
+		)
+	}
+
+	// Samples older than those retained are read from the data file.
+	chk.NoErr(fStore.AddCountWindow("key1", "last3", 3))
+	chk.Err(
+		fStore.AddCountWindow("key1", "last3", 1),
+		ErrDupWinKey.Error(),
+	)
+
+	avg, err := fStore.WindowAverage("key1", "last3")
+	chk.NoErr(err)
+	chk.Float64(avg, 3, 0)
+	chk.Int(fStore.winDB["key1"].count(), 3)
+
+	avg, err = fStore.WindowAverage("key1", "last2")
+	chk.NoErr(err)
+	chk.Float64(avg, 3.5, 0)
+
+	avg, err = fStore.WindowAverage("key1", "last1")
+	chk.NoErr(err)
+	chk.Float64(avg, 4, 0)
+
+	count, err := fStore.WindowCount("key1", "last1")
+	chk.NoErr(err)
+	chk.Uint64(count, 1)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
@@ -20,6 +20,7 @@ package szstore
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -62,18 +63,67 @@ func (e *windowEntry) String() string {
 		strconv.FormatFloat(e.value, 'g', -1, 64)
 }
 
+// windowKind identifies how a window aggregates its measurements.
+type windowKind byte
+
+// Window kinds.
+const (
+	windowSliding  windowKind = 'S' // Entries within the period.
+	windowEWMA     windowKind = 'E' // Exponentially weighted, no entries.
+	windowCount    windowKind = 'N' // The last samples entries.
+	windowTumbling windowKind = 'B' // Wall clock aligned buckets.
+	windowRate     windowKind = 'R' // Counter increase per second.
+)
+
+func (k windowKind) String() string {
+	switch k {
+	case windowSliding:
+		return "S - Sliding"
+	case windowEWMA:
+		return "E - EWMA"
+	case windowCount:
+		return "N - Count"
+	case windowTumbling:
+		return "B - Tumbling"
+	case windowRate:
+		return "R - Rate"
+	default:
+		return "? - WINDOW(" + string(k) + ")"
+	}
+}
+
 // Window represents a specific collection of measurements included in a
 // window's time period.
 type window struct {
 	datKey     string
 	winKey     string
+	values     bool // Holds the key's value thresholds.
+	kind       windowKind
 	period     time.Duration
+	samples    uint64
+	lastTS     time.Time
 	newest     *windowEntry
 	oldest     *windowEntry
 	count      uint64
 	total      float64
 	avg        float64
+	mean       float64
+	m2         float64
+	minQueue   []*windowEntry
+	maxQueue   []*windowEntry
+	ordered    orderTree
+	mode       AverageMode
+	area       float64
+	increase   float64
 	thresholds []*threshold
+
+	// Tumbling window buckets.
+	bucketEnd    time.Time
+	bucketMin    float64
+	bucketMax    float64
+	bucketNotify BucketNotifyFunc
+	bucketLive   bool // The bucket holds a value received while open.
+	completed    *Bucket
 }
 
 func newWindow(datKey, winKey string, timePeriod time.Duration) *window {
@@ -84,7 +134,9 @@ func newWindow(datKey, winKey string, timePeriod time.Duration) *window {
 	newWin := new(window)
 	newWin.datKey = datKey
 	newWin.winKey = winKey
+	newWin.kind = windowSliding
 	newWin.period = timePeriod
+	newWin.mode = AverageSample
 
 	return newWin
 }
@@ -99,13 +151,119 @@ func (w *window) addThreshold(
 		callback,
 	)
 	if err == nil {
+		threshold.id = w.thresholdID(
+			lowCritical, lowWarning, highWarning, highCritical,
+		)
 		w.thresholds = append(w.thresholds, threshold)
 	}
 
 	return err
 }
 
+// thresholdID builds an identity for a new threshold from the limits it is
+// added with so it is independent of the order thresholds are added or
+// removed.  A threshold with the same limits as an existing one is
+// distinguished by a "#n" suffix.
+func (w *window) thresholdID(
+	lowCritical, lowWarning, highWarning, highCritical float64,
+) string {
+	base := formatFloat(lowCritical) + ":" + formatFloat(lowWarning) + ":" +
+		formatFloat(highWarning) + ":" + formatFloat(highCritical)
+
+	id := base
+	for n := 1; slices.ContainsFunc(w.thresholds, func(t *threshold) bool {
+		return t.id == id
+	}); n++ {
+		id = base + "#" + strconv.Itoa(n)
+	}
+
+	return id
+}
+
+// removeThreshold discards the indexed threshold.
+func (w *window) removeThreshold(index int) error {
+	if index < 0 || index >= len(w.thresholds) {
+		return ErrUnknownThreshold
+	}
+
+	w.thresholds = slices.Delete(w.thresholds, index, index+1)
+
+	return nil
+}
+
+// updateThreshold applies a change to the indexed threshold rechecking it if
+// successful.
+func (w *window) updateThreshold(
+	index int, change func(*threshold) error,
+) error {
+	if index < 0 || index >= len(w.thresholds) {
+		return ErrUnknownThreshold
+	}
+
+	t := w.thresholds[index]
+
+	err := change(t)
+	if err == nil {
+		w.recheck(t)
+	}
+
+	return err
+}
+
+// recheck checks a new or changed threshold against the window's current
+// average (if any).
+func (w *window) recheck(t *threshold) {
+	if w.measured() {
+		t.check(w.lastTS, w.avg)
+	}
+}
+
+// measured reports if the window holds an average to check its thresholds
+// against.  A rate window needs two entries to measure a rate.
+func (w *window) measured() bool {
+	if w.kind == windowRate {
+		return w.count > 1
+	}
+
+	return w.count > 0
+}
+
+// stateful reports if the window's state is not derived from the retained
+// entries.
+func (w *window) stateful() bool {
+	return w.kind == windowEWMA || w.kind == windowTumbling
+}
+
 func (w *window) add(newEntry *windowEntry) {
+	w.load(newEntry)
+
+	if w.kind == windowTumbling {
+		w.bucketLive = true
+	}
+
+	if !w.measured() {
+		return
+	}
+
+	for _, t := range w.thresholds {
+		t.check(newEntry.timestamp, w.avg)
+	}
+}
+
+// load incorporates a new entry without checking any thresholds.
+func (w *window) load(newEntry *windowEntry) {
+	switch w.kind {
+	case windowEWMA:
+		w.loadEWMA(newEntry)
+
+		return
+	case windowTumbling:
+		w.loadTumbling(newEntry)
+
+		return
+	default:
+	}
+
 	w.newest = newEntry
 	if w.oldest == nil {
 		// First entry.
@@ -114,12 +272,10 @@ func (w *window) add(newEntry *windowEntry) {
 
 	w.count++
 	w.total += newEntry.value
+	w.lastTS = newEntry.timestamp
+	w.admit(newEntry)
 	w.trim()
-	w.avg = w.total / float64(w.count)
-
-	for _, t := range w.thresholds {
-		t.check(w.avg)
-	}
+	w.avg = w.average()
 }
 
 func (w *window) trim() {
@@ -132,10 +288,16 @@ func (w *window) trim() {
 			return
 		}
 
-		if w.newest.timestamp.Sub(w.oldest.timestamp) <= w.period {
+		if w.kind == windowCount && w.count <= w.samples {
+			return
+		}
+
+		if w.kind != windowCount &&
+			w.newest.timestamp.Sub(w.oldest.timestamp) <= w.period {
 			return
 		}
 
+		w.evict(w.oldest)
 		w.count--
 		w.total -= w.oldest.value
 		w.oldest = w.oldest.prev
@@ -148,10 +310,15 @@ func (w *window) delete() {
 	w.count = 0
 	w.total = 0
 	w.avg = 0
+	w.lastTS = time.Time{}
+	w.bucketEnd = time.Time{}
+	w.bucketLive = false
+	w.completed = nil
+	w.resetAggregates()
 }
 
 func (w *window) getAvg() (float64, error) {
-	if w.count < 1 {
+	if !w.measured() {
 		return 0, ErrNoWinData
 	}
 
@@ -159,7 +326,7 @@ func (w *window) getAvg() (float64, error) {
 }
 
 func (w *window) getCount() (uint64, error) {
-	if w.count < 1 {
+	if !w.measured() {
 		return 0, ErrNoWinData
 	}
 
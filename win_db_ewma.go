@@ -0,0 +1,66 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"math"
+	"time"
+)
+
+// ewmaBackfillHalfLives is the number of half lives of values back-filled
+// into a window added to an opened store.  Older values would carry less
+// than a thousandth of the weight.
+const ewmaBackfillHalfLives = 10
+
+// AddEWMAWindow creates a named exponentially weighted moving average window
+// for the specified key.  The weight of a value halves every halfLife
+// elapsed between samples.  No samples are retained so the window does not
+// extend the history held for the key's other windows.  A window added to
+// an opened store is back-filled with the values of the last ten half
+// lives.
+func (fs *fileStore) AddEWMAWindow(
+	datKey, winKey string, halfLife time.Duration,
+) error {
+	return fs.addKeyWindow(datKey, winKey, func(wdb *winDB) error {
+		return wdb.addEWMAWindow(winKey, halfLife)
+	})
+}
+
+// addEWMAWindow includes a new exponentially weighted window.
+func (wdb *winDB) addEWMAWindow(winKey string, halfLife time.Duration) error {
+	newWin := newWindow(wdb.datKey, winKey, halfLife)
+	newWin.kind = windowEWMA
+
+	return wdb.register(newWin)
+}
+
+// loadEWMA decays the average by the time elapsed since the last sample
+// before incorporating the new value.
+func (w *window) loadEWMA(e *windowEntry) {
+	if w.count == 0 {
+		w.avg = e.value
+	} else {
+		elapsed := max(e.timestamp.Sub(w.lastTS), 0)
+		decay := math.Exp2(-float64(elapsed) / float64(w.period))
+		w.avg = e.value + (w.avg-e.value)*decay
+	}
+
+	w.count++
+	w.lastTS = e.timestamp
+}
@@ -0,0 +1,156 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestWindowWindows_EWMA(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	start := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
+	wdb := newWinDB("datKey1")
+	chk.NoErr(wdb.addEWMAWindow("ewma", time.Second*10))
+	chk.Err(
+		wdb.addEWMAWindow("ewma", time.Second*10), ErrDupWinKey.Error(),
+	)
+
+	var reasons []byte
+
+	chk.NoErr(
+		wdb.addThreshold("ewma", 10, 20, 60, 80,
+			func(_, _ string, _, to ThresholdReason, _ float64) {
+				reasons = append(reasons, byte(to))
+			},
+		),
+	)
+
+	_, err := wdb.getAvg("ewma")
+	chk.Err(err, ErrNoWinData.Error())
+
+	for _, tst := range []struct {
+		second int
+		value  float64
+		avg    float64
+	}{
+		{0, 0, 0},
+		{10, 100, 50},    // One half life.
+		{20, 100, 75},    // Another half life.
+		{20, 100, 75},    // No time elapsed so no weight.
+		{40, 0, 18.75},   // Two half lives.
+		{45, 0, 13.2583}, // Half a half life.
+	} {
+		wdb.addValue(
+			start.Add(time.Duration(tst.second)*time.Second), tst.value,
+		)
+
+		got, err := wdb.getAvg("ewma")
+		chk.NoErr(err)
+		chk.Float64(got, tst.avg, 0.0001, "second: ", tst.second)
+	}
+
+	// No entries are retained beyond the newest.
+	chk.Int(wdb.count(), 1)
+
+	count, err := wdb.getCount("ewma")
+	chk.NoErr(err)
+	chk.Uint64(count, 6)
+
+	// Low critical, normal, high warning and low warning.
+	chk.Str(string(reasons), "cNWw")
+
+	_, err = wdb.getStat("ewma", (*window).getMax)
+	chk.Err(err, ErrWindowKind.Error())
+
+	chk.Err(
+		wdb.setAverageMode("ewma", AverageTime), ErrWindowKind.Error(),
+	)
+
+	wdb.delete()
+
+	_, err = wdb.getAvg("ewma")
+	chk.Err(err, ErrNoWinData.Error())
+}
+
+func TestWindowWindows_EWMAWithSliding(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	setupFloatValues(fStore)
+
+	chk.NoErr(fStore.AddWindow("key1", "sliding", time.Second*2))
+	chk.NoErr(fStore.AddEWMAWindow("key1", "ewma", time.Hour))
+	chk.Err(
+		fStore.AddEWMAWindow("key1", "sliding", time.Hour),
+		ErrDupWinKey.Error(),
+	)
+
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	for _, v := range []float64{1, 2, 3, 4, 5} {
+		chk.NoErr(
+			fStore.update("key1", strconv.FormatFloat(v, 'g', -1, 64), v),
+		)
+	}
+
+	// Values older than those retained are read from the data file.
+	chk.NoErr(fStore.AddEWMAWindow("key1", "late", time.Hour))
+
+	avg, err := fStore.WindowAverage("key1", "ewma")
+	chk.NoErr(err)
+
+	lateAvg, err := fStore.WindowAverage("key1", "late")
+	chk.NoErr(err)
+	chk.Float64(lateAvg, avg, 1e-9)
+
+	// Only the sliding window determines the entries retained.
+	chk.Int(fStore.winDB["key1"].count(), 3)
+
+	count, err := fStore.WindowCount("key1", "ewma")
+	chk.NoErr(err)
+	chk.Uint64(count, 5)
+
+	count, err = fStore.WindowCount("key1", "late")
+	chk.NoErr(err)
+	chk.Uint64(count, 5)
+
+	count, err = fStore.WindowCount("key1", "sliding")
+	chk.NoErr(err)
+	chk.Uint64(count, 3)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
@@ -20,6 +20,7 @@ package szstore
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"time"
 )
@@ -31,8 +32,12 @@ type winDB struct {
 	newestEntry *windowEntry
 	oldestEntry *windowEntry
 	maxPeriod   time.Duration
+	maxSamples  uint64
+	numEntries  uint64
+	keepLeading bool
 	windows     map[string]*window
 	winKeys     []string
+	values      *window
 	cachedEntry *windowEntry
 }
 
@@ -50,41 +55,128 @@ func newWinDB(datKey string) *winDB {
 func (wdb *winDB) addWindow(
 	winKey string, timePeriod time.Duration,
 ) error {
-	if _, ok := wdb.windows[winKey]; ok {
-		return ErrDupWinKey
+	newWin := newWindow(wdb.datKey, winKey, timePeriod)
+
+	err := wdb.register(newWin)
+	if err == nil && newWin.period > wdb.maxPeriod {
+		wdb.maxPeriod = newWin.period
 	}
 
-	newWin := newWindow(wdb.datKey, winKey, timePeriod)
+	return err
+}
 
-	wdb.windows[winKey] = newWin
-	if timePeriod > wdb.maxPeriod {
-		wdb.maxPeriod = timePeriod
+// register includes a new window of any kind.
+func (wdb *winDB) register(newWin *window) error {
+	if _, ok := wdb.windows[newWin.winKey]; ok {
+		return ErrDupWinKey
 	}
 
-	wdb.winKeys = append(wdb.winKeys, winKey)
+	wdb.windows[newWin.winKey] = newWin
+	wdb.winKeys = append(wdb.winKeys, newWin.winKey)
 	sort.Strings(wdb.winKeys)
 
 	return nil
 }
 
-// addValue incorporates a new value into the underlying store.
+// removeWindow discards the named window recalculating the period and
+// number of samples the shared entry list must retain.
+func (wdb *winDB) removeWindow(winKey string) error {
+	if _, ok := wdb.windows[winKey]; !ok {
+		return ErrUnknownWinKey
+	}
+
+	delete(wdb.windows, winKey)
+	wdb.winKeys = slices.DeleteFunc(wdb.winKeys, func(k string) bool {
+		return k == winKey
+	})
+
+	wdb.maxPeriod = time.Nanosecond
+	wdb.maxSamples = 0
+	wdb.keepLeading = false
+
+	for _, w := range wdb.windows {
+		switch w.kind {
+		case windowSliding, windowRate:
+			wdb.maxPeriod = max(wdb.maxPeriod, w.period)
+		case windowCount:
+			wdb.maxSamples = max(wdb.maxSamples, w.samples)
+		default:
+		}
+
+		if w.mode == AverageTime {
+			wdb.keepLeading = true
+		}
+	}
+
+	return nil
+}
+
+// addValue incorporates a new value into the underlying store checking all
+// window thresholds.
 func (wdb *winDB) addValue(timestamp time.Time, value float64) {
+	newEntry := wdb.newEntry(timestamp, value)
+
+	for _, wk := range wdb.winKeys {
+		wdb.windows[wk].add(newEntry)
+	}
+
+	if wdb.values != nil {
+		wdb.values.add(newEntry)
+	}
+
+	wdb.trim()
+}
+
+// loadValue incorporates a historical value into the underlying store
+// without checking any window thresholds.
+func (wdb *winDB) loadValue(timestamp time.Time, value float64) {
+	newEntry := wdb.newEntry(timestamp, value)
+
+	for _, wk := range wdb.winKeys {
+		wdb.windows[wk].load(newEntry)
+	}
+
+	if wdb.values != nil {
+		wdb.values.load(newEntry)
+	}
+
+	wdb.trim()
+}
+
+// newEntry links a new (or recycled) entry to the head of the list.
+func (wdb *winDB) newEntry(timestamp time.Time, value float64) *windowEntry {
 	e := wdb.cachedEntry
 	if e != nil {
 		wdb.cachedEntry = e.next
 	}
 
 	wdb.newestEntry = wdb.newestEntry.newHead(e, timestamp, value)
+	wdb.numEntries++
 
 	if wdb.oldestEntry == nil {
 		wdb.oldestEntry = wdb.newestEntry
 	}
 
-	for _, wk := range wdb.winKeys {
-		wdb.windows[wk].add(wdb.newestEntry)
+	return wdb.newestEntry
+}
+
+// newTail links a new (or recycled) entry older than all others to the tail
+// of the list.
+func (wdb *winDB) newTail(timestamp time.Time, value float64) {
+	e := wdb.cachedEntry
+	if e != nil {
+		wdb.cachedEntry = e.next
+	} else {
+		e = new(windowEntry)
 	}
 
-	wdb.trim()
+	e.timestamp = timestamp
+	e.value = value
+	e.next = nil
+	e.prev = wdb.oldestEntry
+	wdb.oldestEntry.next = e
+	wdb.oldestEntry = e
+	wdb.numEntries++
 }
 
 // getAvg returns the average over the entire sample.
@@ -97,6 +189,18 @@ func (wdb *winDB) getAvg(winKey string) (float64, error) {
 	return dw.getAvg()
 }
 
+// getStat returns a statistic of the named window.
+func (wdb *winDB) getStat(
+	winKey string, stat func(*window) (float64, error),
+) (float64, error) {
+	dw, ok := wdb.windows[winKey]
+	if !ok {
+		return 0, ErrUnknownWinKey
+	}
+
+	return stat(dw)
+}
+
 // getCount returns the number of samples in the window.
 func (wdb *winDB) getCount(winKey string) (uint64, error) {
 	dw, ok := wdb.windows[winKey]
@@ -113,6 +217,10 @@ func (wdb *winDB) delete() {
 		w.delete()
 	}
 
+	if wdb.values != nil {
+		wdb.values.delete()
+	}
+
 	if wdb.oldestEntry != nil {
 		wdb.oldestEntry.next = wdb.cachedEntry
 	}
@@ -120,12 +228,14 @@ func (wdb *winDB) delete() {
 	wdb.cachedEntry = wdb.newestEntry
 	wdb.newestEntry = nil
 	wdb.oldestEntry = nil
+	wdb.numEntries = 0
 }
 
 func (wdb *winDB) trim() {
 	nt := wdb.newestEntry.timestamp
 	for wdb.oldestEntry.prev != nil &&
-		nt.Sub(wdb.oldestEntry.timestamp) > wdb.maxPeriod {
+		wdb.numEntries > wdb.maxSamples &&
+		wdb.canTrimOldest(nt) {
 		//	log.Printf("Removing oldest")
 		e := wdb.oldestEntry
 		wdb.oldestEntry = e.prev
@@ -133,6 +243,7 @@ func (wdb *winDB) trim() {
 		e.prev = nil
 		e.next = wdb.cachedEntry
 		wdb.cachedEntry = e
+		wdb.numEntries--
 	}
 }
 
@@ -150,6 +261,26 @@ func (wdb *winDB) addThreshold(winKey string,
 	)
 }
 
+func (wdb *winDB) removeThreshold(winKey string, index int) error {
+	dw, ok := wdb.windows[winKey]
+	if !ok {
+		return ErrUnknownWinKey
+	}
+
+	return dw.removeThreshold(index)
+}
+
+func (wdb *winDB) updateThreshold(
+	winKey string, index int, change func(*threshold) error,
+) error {
+	dw, ok := wdb.windows[winKey]
+	if !ok {
+		return ErrUnknownWinKey
+	}
+
+	return dw.updateThreshold(index, change)
+}
+
 func (wdb *winDB) count() int {
 	numEntries := 0
 	entry := wdb.newestEntry
@@ -19,6 +19,7 @@
 package szstore
 
 import (
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -481,3 +482,82 @@ func TestWindowWindows_Thresholds(t *testing.T) {
 	chk.True(callback1Triggered)
 	chk.True(callback2Triggered)
 }
+
+func TestWindowWindows_Aggregates(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	chk.ClockSet(
+		time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local),
+		time.Second,
+	)
+
+	wdb := newWinDB("datKey1")
+	chk.NoErr(wdb.addWindow("winKey1", time.Second*3))
+
+	for _, stat := range []func(*window) (float64, error){
+		(*window).getMin, (*window).getMax, (*window).getSum,
+		(*window).getVariance, (*window).getStdDev,
+	} {
+		_, err := wdb.getStat("winKey1", stat)
+		chk.Err(err, ErrNoWinData.Error())
+		_, err = wdb.getStat("unknown", stat)
+		chk.Err(err, ErrUnknownWinKey.Error())
+	}
+
+	values := []float64{5, 3, 8, 8, 1, 9, 2, 2, 7, -4, 6}
+
+	for i, v := range values {
+		wdb.addValue(chk.ClockNext(), v)
+
+		// The window holds the latest four values (3 seconds apart).
+		expected := values[max(i-3, 0) : i+1]
+
+		minValue, maxValue, sum := expected[0], expected[0], 0.0
+		for _, e := range expected {
+			minValue = min(minValue, e)
+			maxValue = max(maxValue, e)
+			sum += e
+		}
+
+		mean := sum / float64(len(expected))
+		variance := 0.0
+
+		for _, e := range expected {
+			variance += (e - mean) * (e - mean)
+		}
+
+		variance /= float64(len(expected))
+
+		got, err := wdb.getStat("winKey1", (*window).getMin)
+		chk.NoErr(err)
+		chk.Float64(got, minValue, 0)
+
+		got, err = wdb.getStat("winKey1", (*window).getMax)
+		chk.NoErr(err)
+		chk.Float64(got, maxValue, 0)
+
+		got, err = wdb.getStat("winKey1", (*window).getSum)
+		chk.NoErr(err)
+		chk.Float64(got, sum, 0)
+
+		got, err = wdb.getStat("winKey1", (*window).getVariance)
+		chk.NoErr(err)
+		chk.Float64(got, variance, 1e-9)
+
+		got, err = wdb.getStat("winKey1", (*window).getStdDev)
+		chk.NoErr(err)
+		chk.Float64(got, math.Sqrt(variance), 1e-9)
+	}
+
+	wdb.delete()
+
+	_, err := wdb.getStat("winKey1", (*window).getMax)
+	chk.Err(err, ErrNoWinData.Error())
+
+	wdb.addValue(chk.ClockNext(), 42)
+
+	got, err := wdb.getStat("winKey1", (*window).getStdDev)
+	chk.NoErr(err)
+	chk.Float64(got, 0, 0)
+}
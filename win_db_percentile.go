@@ -0,0 +1,205 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+)
+
+// orderNode is a node of an order statistics treap holding each distinct
+// value once along with the number of times it occurs.
+type orderNode struct {
+	value    float64
+	count    int
+	size     int // Total occurrences in this subtree.
+	priority uint32
+	left     *orderNode
+	right    *orderNode
+}
+
+func (n *orderNode) getSize() int {
+	if n == nil {
+		return 0
+	}
+
+	return n.size
+}
+
+func (n *orderNode) resize() {
+	n.size = n.left.getSize() + n.count + n.right.getSize()
+}
+
+func (n *orderNode) rotateRight() *orderNode {
+	l := n.left
+	n.left = l.right
+	l.right = n
+
+	n.resize()
+	l.resize()
+
+	return l
+}
+
+func (n *orderNode) rotateLeft() *orderNode {
+	r := n.right
+	n.right = r.left
+	r.left = n
+
+	n.resize()
+	r.resize()
+
+	return r
+}
+
+// orderTree maintains the multiset of values in a window permitting any
+// rank to be selected in logarithmic time.  Values are ordered by
+// cmp.Compare so a NaN sample is ranked below all others (and found again
+// when it is removed).
+type orderTree struct {
+	root *orderNode
+}
+
+func (t *orderTree) len() int {
+	return t.root.getSize()
+}
+
+func (t *orderTree) insert(value float64) {
+	t.root = insertOrder(t.root, value)
+}
+
+func (t *orderTree) remove(value float64) {
+	t.root = removeOrder(t.root, value)
+}
+
+func insertOrder(n *orderNode, value float64) *orderNode {
+	switch {
+	case n == nil:
+		return &orderNode{
+			value:    value,
+			count:    1,
+			size:     1,
+			priority: rand.Uint32(), //nolint:gosec // Ok.
+		}
+	case cmp.Compare(value, n.value) == 0:
+		n.count++
+	case cmp.Less(value, n.value):
+		n.left = insertOrder(n.left, value)
+		if n.left.priority > n.priority {
+			n = n.rotateRight()
+		}
+	default:
+		n.right = insertOrder(n.right, value)
+		if n.right.priority > n.priority {
+			n = n.rotateLeft()
+		}
+	}
+
+	n.resize()
+
+	return n
+}
+
+func removeOrder(n *orderNode, value float64) *orderNode {
+	switch {
+	case n == nil:
+		return nil
+	case cmp.Less(value, n.value):
+		n.left = removeOrder(n.left, value)
+	case cmp.Less(n.value, value):
+		n.right = removeOrder(n.right, value)
+	case n.count > 1:
+		n.count--
+	default:
+		return removeOrderNode(n)
+	}
+
+	n.resize()
+
+	return n
+}
+
+// removeOrderNode rotates the node down until it has at most one child
+// and unlinks it.
+func removeOrderNode(n *orderNode) *orderNode {
+	switch {
+	case n.left == nil:
+		return n.right
+	case n.right == nil:
+		return n.left
+	case n.left.priority > n.right.priority:
+		n = n.rotateRight()
+		n.right = removeOrderNode(n.right)
+	default:
+		n = n.rotateLeft()
+		n.left = removeOrderNode(n.left)
+	}
+
+	n.resize()
+
+	return n
+}
+
+// kth returns the value with the provided zero based rank.
+func (t *orderTree) kth(rank int) float64 {
+	n := t.root
+
+	for {
+		leftSize := n.left.getSize()
+
+		switch {
+		case rank < leftSize:
+			n = n.left
+		case rank < leftSize+n.count:
+			return n.value
+		default:
+			rank -= leftSize + n.count
+			n = n.right
+		}
+	}
+}
+
+// percentile returns the q (0 <= q <= 1) percentile of the values linearly
+// interpolating between the closest ranks.
+func (t *orderTree) percentile(q float64) float64 {
+	rank := q * float64(t.len()-1)
+	lo := math.Floor(rank)
+	loValue := t.kth(int(lo))
+
+	if rank == lo {
+		return loValue
+	}
+
+	return loValue + (t.kth(int(lo)+1)-loValue)*(rank-lo)
+}
+
+// getPercentile returns the exact q (0 <= q <= 1) percentile of the samples
+// in the window.
+func (w *window) getPercentile(q float64) (float64, error) {
+	if math.IsNaN(q) || q < 0 || q > 1 {
+		return 0, ErrInvalidPercentile
+	}
+
+	if err := w.retained(); err != nil {
+		return 0, err
+	}
+
+	return w.ordered.percentile(q), nil
+}
@@ -0,0 +1,159 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestOrderTree_RandomAgainstSorted(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	var (
+		tree   orderTree
+		values []float64
+	)
+
+	rnd := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // Ok.
+
+	for i := range 500 {
+		if len(values) > 0 && rnd.IntN(3) == 0 {
+			idx := rnd.IntN(len(values))
+			tree.remove(values[idx])
+			values = slices.Delete(values, idx, idx+1)
+		} else {
+			v := float64(rnd.IntN(50)) // Plenty of duplicates.
+			tree.insert(v)
+			values = append(values, v)
+		}
+
+		chk.Int(tree.len(), len(values), "iteration: ", i)
+
+		sorted := slices.Sorted(slices.Values(values))
+		for rank, v := range sorted {
+			chk.Float64(tree.kth(rank), v, 0, "iteration: ", i)
+		}
+	}
+}
+
+func TestWindowWindows_Percentile(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	chk.ClockSet(
+		time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local),
+		time.Second,
+	)
+
+	wdb := newWinDB("datKey1")
+	chk.NoErr(wdb.addWindow("winKey1", time.Second*4))
+
+	percentile := func(q float64) (float64, error) {
+		return wdb.getStat("winKey1", func(w *window) (float64, error) {
+			return w.getPercentile(q)
+		})
+	}
+
+	_, err := percentile(0.5)
+	chk.Err(err, ErrNoWinData.Error())
+
+	for _, v := range []float64{100, 15, 20, 35, 50, 40} {
+		wdb.addValue(chk.ClockNext(), v)
+	}
+
+	// Window now holds: 15, 20, 35, 50, 40 (100 has been evicted).
+	for _, tst := range []struct {
+		q, expected float64
+	}{
+		{0, 15},
+		{0.25, 20},
+		{0.5, 35},
+		{0.9, 46},
+		{0.99, 49.6},
+		{1, 50},
+	} {
+		got, err := percentile(tst.q)
+		chk.NoErr(err)
+		chk.Float64(got, tst.expected, 1e-9, "q: ", tst.q)
+	}
+
+	for _, q := range []float64{-0.1, 1.1, math.NaN()} {
+		_, err = percentile(q)
+		chk.Err(err, ErrInvalidPercentile.Error())
+	}
+
+	wdb.delete()
+
+	_, err = percentile(0.5)
+	chk.Err(err, ErrNoWinData.Error())
+}
+
+func TestWindowWindows_PercentileNaN(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	chk.ClockSet(
+		time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local),
+		time.Second,
+	)
+
+	wdb := newWinDB("datKey1")
+	chk.NoErr(wdb.addCountWindow("last3", 3))
+
+	stat := func(get func(*window) (float64, error)) float64 {
+		got, err := wdb.getStat("last3", get)
+		chk.NoErr(err)
+
+		return got
+	}
+
+	percentile := func(q float64) float64 {
+		return stat(func(w *window) (float64, error) {
+			return w.getPercentile(q)
+		})
+	}
+
+	for _, v := range []float64{1, math.NaN(), 2} {
+		wdb.addValue(chk.ClockNext(), v)
+	}
+
+	// A NaN sample is ranked below all others.
+	chk.True(math.IsNaN(percentile(0)))
+	chk.Float64(percentile(1), 2, 0)
+	chk.True(math.IsNaN(stat((*window).getMin)))
+	chk.Float64(stat((*window).getMax), 2, 0)
+
+	// Evicting the NaN leaves the other values in place.
+	for _, v := range []float64{3, 4, 5} {
+		wdb.addValue(chk.ClockNext(), v)
+	}
+
+	chk.Float64(percentile(0), 3, 0)
+	chk.Float64(percentile(0.5), 4, 0)
+	chk.Float64(percentile(1), 5, 0)
+	chk.Float64(stat((*window).getMin), 3, 0)
+	chk.Float64(stat((*window).getMax), 5, 0)
+}
@@ -0,0 +1,89 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"time"
+)
+
+// AddRateWindow creates a named window for the specified key reporting the
+// per second rate of increase of a counter between the oldest and newest
+// samples within the time period.  A decreasing value is treated as a
+// counter reset (restarting from zero) so the new value is counted as the
+// increase.  The rate is reported as the window's average and is checked
+// against any of the window's thresholds once the window holds the two
+// samples needed to measure it.  A window added to an opened store is
+// back-filled like those added by AddWindow.
+func (fs *fileStore) AddRateWindow(
+	datKey, winKey string, period time.Duration,
+) error {
+	return fs.addKeyWindow(datKey, winKey, func(wdb *winDB) error {
+		return wdb.addRateWindow(winKey, period)
+	})
+}
+
+// addRateWindow includes a new counter rate window.  Like a sliding window
+// the shared entry list retains its entire period.
+func (wdb *winDB) addRateWindow(winKey string, period time.Duration) error {
+	newWin := newWindow(wdb.datKey, winKey, period)
+	newWin.kind = windowRate
+
+	err := wdb.register(newWin)
+	if err == nil && newWin.period > wdb.maxPeriod {
+		wdb.maxPeriod = newWin.period
+	}
+
+	return err
+}
+
+// counterIncrease returns the increase of a counter from one value to the
+// next treating a decrease as a reset.
+func counterIncrease(from, to float64) float64 {
+	if to < from {
+		return to
+	}
+
+	return to - from
+}
+
+// admitIncrease adds the increase from the previous newest entry to the new
+// entry.
+func (w *window) admitIncrease(e *windowEntry) {
+	if w.count > 1 {
+		w.increase += counterIncrease(e.next.value, e.value)
+	}
+}
+
+// evictIncrease removes the increase from the oldest entry to its successor.
+func (w *window) evictIncrease(e *windowEntry) {
+	if e.prev != nil {
+		w.increase -= counterIncrease(e.value, e.prev.value)
+	}
+}
+
+// rate returns the per second increase over the retained entries.  A single
+// entry has no rate.
+func (w *window) rate() float64 {
+	elapsed := w.newest.timestamp.Sub(w.oldest.timestamp)
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return w.increase / elapsed.Seconds()
+}
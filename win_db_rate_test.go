@@ -0,0 +1,134 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestWindowWindows_Rate(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	ts := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
+	wdb := newWinDB("datKey1")
+	chk.NoErr(wdb.addRateWindow("rate", time.Second*10))
+	chk.Err(wdb.addRateWindow("rate", time.Second), ErrDupWinKey.Error())
+
+	var reasons []byte
+
+	chk.NoErr(
+		wdb.addThreshold("rate", 0.5, 0.9, 12, 14,
+			func(_, _ string, _, to ThresholdReason, _ float64) {
+				reasons = append(reasons, byte(to))
+			},
+		),
+	)
+
+	_, err := wdb.getAvg("rate")
+	chk.Err(err, ErrNoWinData.Error())
+
+	for _, tst := range []struct {
+		gap   time.Duration
+		value float64
+		rate  float64
+		count uint64
+	}{
+		{0, 100, 0, 0}, // A single sample has no rate.
+		{time.Second, 110, 10, 2},
+		{time.Second, 130, 15, 3},
+		{time.Second, 10, 40.0 / 3, 4}, // Counter reset.
+		{time.Second * 10, 20, 1, 2},
+		{time.Second * 11, 30, 0, 0}, // A gap past the period has no rate.
+	} {
+		ts = ts.Add(tst.gap)
+		wdb.addValue(ts, tst.value)
+
+		info := wdb.windows["rate"].info()
+		chk.Float64(info.Avg, tst.rate, 1e-9)
+		chk.Uint64(info.Count, tst.count)
+
+		got, err := wdb.getAvg("rate")
+		count, cntErr := wdb.getCount("rate")
+
+		if tst.count == 0 {
+			chk.Err(err, ErrNoWinData.Error())
+			chk.Err(cntErr, ErrNoWinData.Error())
+
+			continue
+		}
+
+		chk.NoErr(err)
+		chk.Float64(got, tst.rate, 1e-9)
+		chk.NoErr(cntErr)
+		chk.Uint64(count, tst.count)
+	}
+
+	// Normal, high critical, high warning, normal.  A window without a rate
+	// is not checked.
+	chk.Str(string(reasons), "NCWN")
+
+	chk.Err(
+		wdb.setAverageMode("rate", AverageTime), ErrWindowKind.Error(),
+	)
+}
+
+func TestWindowWindows_AddRateWindow(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.AddRateWindow("key1", "rate", time.Minute))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	for _, v := range []uint64{1000, 3000, 5000} {
+		chk.NoErr(fStore.update("key1", "v", float64(v)))
+	}
+
+	rate, err := fStore.WindowAverage("key1", "rate")
+	chk.NoErr(err)
+	chk.Float64(rate, 2000, 0)
+
+	// Back-filled from the entries held for the key.
+	chk.NoErr(fStore.AddRateWindow("key1", "short", time.Second))
+
+	rate, err = fStore.WindowAverage("key1", "short")
+	chk.NoErr(err)
+	chk.Float64(rate, 2000, 0)
+
+	count, err := fStore.WindowCount("key1", "short")
+	chk.NoErr(err)
+	chk.Uint64(count, 2)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
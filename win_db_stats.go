@@ -0,0 +1,146 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"cmp"
+	"math"
+)
+
+// admit incorporates a new entry into the window's running aggregates.  The
+// minimum and maximum are kept as monotonic queues of the entries that may
+// yet become the extreme value while the variance is maintained with
+// Welford's algorithm.  All values are also kept in an order statistics tree
+// for percentiles.  Values are ordered by cmp.Compare (NaN first).
+func (w *window) admit(e *windowEntry) {
+	w.ordered.insert(e.value)
+	w.admitArea(e)
+	w.admitIncrease(e)
+
+	for n := len(w.minQueue); n > 0 &&
+		cmp.Compare(w.minQueue[n-1].value, e.value) >= 0; n-- {
+		w.minQueue = w.minQueue[:n-1]
+	}
+
+	w.minQueue = append(w.minQueue, e)
+
+	for n := len(w.maxQueue); n > 0 &&
+		cmp.Compare(w.maxQueue[n-1].value, e.value) <= 0; n-- {
+		w.maxQueue = w.maxQueue[:n-1]
+	}
+
+	w.maxQueue = append(w.maxQueue, e)
+
+	delta := e.value - w.mean
+	w.mean += delta / float64(w.count)
+	w.m2 += delta * (e.value - w.mean)
+}
+
+// evict removes the oldest entry from the window's running aggregates.  It
+// must be called before the count is decremented.
+func (w *window) evict(e *windowEntry) {
+	w.ordered.remove(e.value)
+	w.evictArea(e)
+	w.evictIncrease(e)
+
+	if len(w.minQueue) > 0 && w.minQueue[0] == e {
+		w.minQueue = w.minQueue[1:]
+	}
+
+	if len(w.maxQueue) > 0 && w.maxQueue[0] == e {
+		w.maxQueue = w.maxQueue[1:]
+	}
+
+	if w.count < 2 { //nolint:mnd // Ok.
+		w.mean = 0
+		w.m2 = 0
+
+		return
+	}
+
+	remaining := float64(w.count - 1)
+	oldMean := w.mean
+	w.mean = (oldMean*float64(w.count) - e.value) / remaining
+	w.m2 = max(w.m2-(e.value-oldMean)*(e.value-w.mean), 0)
+}
+
+// resetAggregates clears the running aggregates.
+func (w *window) resetAggregates() {
+	w.minQueue = nil
+	w.maxQueue = nil
+	w.ordered = orderTree{}
+	w.mean = 0
+	w.m2 = 0
+	w.area = 0
+	w.increase = 0
+}
+
+// retained checks the window holds samples to calculate statistics from.
+func (w *window) retained() error {
+	if w.kind == windowEWMA || w.kind == windowTumbling {
+		return ErrWindowKind
+	}
+
+	if w.count < 1 {
+		return ErrNoWinData
+	}
+
+	return nil
+}
+
+func (w *window) getMin() (float64, error) {
+	if err := w.retained(); err != nil {
+		return 0, err
+	}
+
+	return w.minQueue[0].value, nil
+}
+
+func (w *window) getMax() (float64, error) {
+	if err := w.retained(); err != nil {
+		return 0, err
+	}
+
+	return w.maxQueue[0].value, nil
+}
+
+func (w *window) getSum() (float64, error) {
+	if err := w.retained(); err != nil {
+		return 0, err
+	}
+
+	return w.total, nil
+}
+
+// getVariance returns the population variance of the window's samples.
+func (w *window) getVariance() (float64, error) {
+	if err := w.retained(); err != nil {
+		return 0, err
+	}
+
+	return w.m2 / float64(w.count), nil
+}
+
+// getStdDev returns the population standard deviation of the window's
+// samples.
+func (w *window) getStdDev() (float64, error) {
+	variance, err := w.getVariance()
+
+	return math.Sqrt(variance), err
+}
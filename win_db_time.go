@@ -0,0 +1,163 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"time"
+)
+
+// AverageMode indicates how a window's average is calculated.
+type AverageMode byte
+
+// AverageMode constants.
+const (
+	AverageSample AverageMode = 'S'
+	AverageTime   AverageMode = 'T'
+)
+
+func (m AverageMode) String() string {
+	switch m {
+	case AverageSample:
+		return "S - Sample"
+	case AverageTime:
+		return "T - Time"
+	default:
+		return "? - AVERAGE(" + string(m) + ")"
+	}
+}
+
+// IsOK checks that the average mode is valid.
+func (m AverageMode) IsOK() bool {
+	return m == AverageSample || m == AverageTime
+}
+
+// SetWindowAverageMode determines how the window's average is calculated.
+// AverageSample (the default) weighs every sample equally while AverageTime
+// weighs each value by how long it was held (until the next sample) over the
+// window's period.  The value held at the start of the period (the last one
+// received before it) is included.  Changing the mode of a window on an
+// opened store recalculates it from the entries held for the key (and the
+// data files) checking its thresholds against the new average.
+func (fs *fileStore) SetWindowAverageMode(
+	datKey, winKey string, mode AverageMode,
+) error {
+	defer fs.dispatch()
+
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if !mode.IsOK() {
+		return ErrInvalidAverageMode
+	}
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return ErrUnknownDatKey
+	}
+
+	err := dw.setAverageMode(winKey, mode)
+	if err == nil && fs.opened {
+		w := dw.windows[winKey]
+		w.delete()
+		fs.backfillWindow(dw, w)
+
+		for _, t := range w.thresholds {
+			w.recheck(t)
+		}
+	}
+
+	return err
+}
+
+// setAverageMode sets the named window's average mode.  Time weighted
+// windows require the entry preceding the longest window be retained.
+func (wdb *winDB) setAverageMode(winKey string, mode AverageMode) error {
+	dw, ok := wdb.windows[winKey]
+	if !ok {
+		return ErrUnknownWinKey
+	}
+
+	if dw.kind != windowSliding {
+		return ErrWindowKind
+	}
+
+	dw.mode = mode
+
+	if mode == AverageTime {
+		wdb.keepLeading = true
+	}
+
+	return nil
+}
+
+// admitArea adds the area of the segment held by the previous newest entry
+// until the arrival of the new entry.
+func (w *window) admitArea(e *windowEntry) {
+	if w.count > 1 {
+		w.area += e.next.value * e.timestamp.Sub(e.next.timestamp).Seconds()
+	}
+}
+
+// evictArea removes the area of the segment held by the oldest entry until
+// its successor arrived.
+func (w *window) evictArea(e *windowEntry) {
+	if e.prev != nil {
+		w.area -= e.value * e.prev.timestamp.Sub(e.timestamp).Seconds()
+	}
+}
+
+// average returns the window's average according to its mode (or its rate
+// for counter rate windows).
+func (w *window) average() float64 {
+	if w.kind == windowRate {
+		return w.rate()
+	}
+
+	if w.mode != AverageTime {
+		return w.total / float64(w.count)
+	}
+
+	area := w.area
+	duration := w.newest.timestamp.Sub(w.oldest.timestamp)
+
+	start := w.newest.timestamp.Add(-w.period)
+	if leading := w.oldest.next; leading != nil &&
+		leading.timestamp.Before(start) {
+		area += leading.value * w.oldest.timestamp.Sub(start).Seconds()
+		duration = w.period
+	}
+
+	if duration <= 0 {
+		return w.total / float64(w.count)
+	}
+
+	return area / duration.Seconds()
+}
+
+// canTrimOldest reports if the oldest entry may be discarded.  If the
+// leading entry must be retained the oldest is only discarded once its
+// successor is at or before the start of the longest window.
+func (wdb *winDB) canTrimOldest(newest time.Time) bool {
+	oldest := wdb.oldestEntry
+	if !wdb.keepLeading {
+		return newest.Sub(oldest.timestamp) > wdb.maxPeriod
+	}
+
+	return newest.Sub(oldest.prev.timestamp) >= wdb.maxPeriod
+}
@@ -0,0 +1,184 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestImpl_AverageMode(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	chk.True(AverageSample.IsOK())
+	chk.True(AverageTime.IsOK())
+	chk.False(AverageMode('B').IsOK())
+
+	chk.Str(AverageSample.String(), "S - Sample")
+	chk.Str(AverageTime.String(), "T - Time")
+	chk.Str(AverageMode('B').String(), "? - AVERAGE(B)")
+}
+
+func TestWindowWindows_TimeWeighted(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	start := time.Date(2020, time.January, 1, 2, 3, 4, 0, time.Local)
+
+	wdb := newWinDB("datKey1")
+	chk.NoErr(wdb.addWindow("sample", time.Second*10))
+	chk.NoErr(wdb.addWindow("time", time.Second*10))
+	chk.NoErr(wdb.setAverageMode("time", AverageTime))
+	chk.Err(
+		wdb.setAverageMode("unknown", AverageTime),
+		ErrUnknownWinKey.Error(),
+	)
+
+	for _, tst := range []struct {
+		second     int
+		value      float64
+		sampleAvg  float64
+		timeAvg    float64
+		numEntries int
+	}{
+		{0, 10, 10, 10, 1},         // A single sample.
+		{2, 20, 15, 10, 2},         // 10 held for 2s.
+		{9, 0, 10, 160.0 / 9.0, 3}, // Plus 20 held for 7s.
+		{14, 30, 15, 10, 3},        // 20 from the leading edge for 5s.
+		{30, 5, 5, 30, 2},          // 30 held over the whole window.
+		{35, 15, 10, (30*5 + 5*5) / 10.0, 3},
+	} {
+		wdb.addValue(
+			start.Add(time.Duration(tst.second)*time.Second), tst.value,
+		)
+
+		got, err := wdb.getAvg("sample")
+		chk.NoErr(err)
+		chk.Float64(got, tst.sampleAvg, 1e-9, "second: ", tst.second)
+
+		got, err = wdb.getAvg("time")
+		chk.NoErr(err)
+		chk.Float64(got, tst.timeAvg, 1e-9, "second: ", tst.second)
+
+		chk.Int(wdb.count(), tst.numEntries, "second: ", tst.second)
+	}
+}
+
+func TestWindowWindows_SetAverageMode(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.AddWindow("key1", "w1", time.Minute))
+
+	chk.Err(
+		fStore.SetWindowAverageMode("key1", "w1", AverageMode('B')),
+		ErrInvalidAverageMode.Error(),
+	)
+	chk.Err(
+		fStore.SetWindowAverageMode("unknown", "w1", AverageTime),
+		ErrUnknownDatKey.Error(),
+	)
+	chk.Err(
+		fStore.SetWindowAverageMode("key1", "unknown", AverageTime),
+		ErrUnknownWinKey.Error(),
+	)
+	chk.NoErr(fStore.SetWindowAverageMode("key1", "w1", AverageTime))
+
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	for _, v := range []float64{10, 20, 30} {
+		chk.NoErr(fStore.update("key1", "v", v))
+	}
+
+	avg, err := fStore.WindowAverage("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(avg, 15, 1e-9)
+
+	// The window is recalculated on an opened store.
+	chk.NoErr(fStore.SetWindowAverageMode("key1", "w1", AverageSample))
+
+	avg, err = fStore.WindowAverage("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(avg, 20, 1e-9)
+
+	chk.NoErr(fStore.SetWindowAverageMode("key1", "w1", AverageTime))
+
+	avg, err = fStore.WindowAverage("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(avg, 15, 1e-9)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestWindowWindows_SetAverageModeLeading(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second, time.Second*8, time.Second*5,
+	)
+
+	setupFloatValues(fStore)
+
+	chk.NoErr(fStore.AddWindow("key1", "w1", time.Second*10))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	// Samples at 0s, 8s and 13s.
+	for _, v := range []float64{0, 10, 10} {
+		chk.NoErr(
+			fStore.update("key1", strconv.FormatFloat(v, 'g', -1, 64), v),
+		)
+	}
+
+	avg, err := fStore.WindowAverage("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(avg, 10, 1e-9)
+
+	// The value before the window (dropped from memory) is read back from
+	// the data file to weigh the start of the window.
+	chk.NoErr(fStore.SetWindowAverageMode("key1", "w1", AverageTime))
+
+	avg, err = fStore.WindowAverage("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(avg, 5, 1e-9)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
@@ -0,0 +1,210 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+const (
+	hoursPerDay           = 24
+	bucketChecksPerPeriod = 60
+)
+
+// Bucket summarizes the values received during a completed tumbling window
+// period.
+type Bucket struct {
+	DatKey string
+	WinKey string
+	Start  time.Time
+	End    time.Time
+	Count  uint64
+	Avg    float64
+	Min    float64
+	Max    float64
+}
+
+// BucketNotifyFunc defines the tumbling window callback function invoked as
+// each bucket is completed.
+type BucketNotifyFunc func(Bucket)
+
+// AddTumblingWindow creates a named window for the specified key that
+// summarizes its values over consecutive periods aligned to the wall clock
+// (starting at midnight).  The period must evenly divide a day (a minute,
+// fifteen minutes, an hour, ...).  A bucket is completed (and passed to the
+// notify function) once its end is reached by the store clock (checked by a
+// background ticker) or the first value after its end is received.  Close
+// delivers the open bucket as it stands; a store reopened before its end
+// continues the bucket delivering it again once complete.  Periods without
+// any values produce no buckets.  A window added to an opened store is
+// back-filled with the values already received in the current bucket.
+func (fs *fileStore) AddTumblingWindow(
+	datKey, winKey string, period time.Duration, notifyFunc BucketNotifyFunc,
+) error {
+	return fs.addKeyWindow(datKey, winKey, func(wdb *winDB) error {
+		return wdb.addTumblingWindow(winKey, period, notifyFunc)
+	})
+}
+
+// addTumblingWindow includes a new wall clock aligned tumbling window.
+func (wdb *winDB) addTumblingWindow(
+	winKey string, period time.Duration, notifyFunc BucketNotifyFunc,
+) error {
+	if period <= 0 || (hoursPerDay*time.Hour)%period != 0 {
+		return ErrInvalidBucketPeriod
+	}
+
+	if notifyFunc == nil {
+		return ErrNilNotifyFunc
+	}
+
+	newWin := newWindow(wdb.datKey, winKey, period)
+	newWin.kind = windowTumbling
+	newWin.bucketNotify = notifyFunc
+
+	return wdb.register(newWin)
+}
+
+// bucketStart returns the start of the bucket containing t.
+func (w *window) bucketStart(t time.Time) time.Time {
+	midnight := RotateDaily.start(t)
+
+	return midnight.Add(t.Sub(midnight) / w.period * w.period)
+}
+
+// loadTumbling incorporates the value into the current bucket first
+// completing the previous bucket if the value falls after its end.
+func (w *window) loadTumbling(e *windowEntry) {
+	w.completed = nil
+	w.completeBucket(e.timestamp)
+
+	if w.count == 0 {
+		w.bucketEnd = w.bucketStart(e.timestamp).Add(w.period)
+		w.bucketMin = e.value
+		w.bucketMax = e.value
+	}
+
+	w.count++
+	w.total += e.value
+	w.bucketMin = min(w.bucketMin, e.value)
+	w.bucketMax = max(w.bucketMax, e.value)
+	w.avg = w.total / float64(w.count)
+	w.lastTS = e.timestamp
+}
+
+// completeBucket closes the current bucket if it has ended by now.
+func (w *window) completeBucket(now time.Time) {
+	if w.count > 0 && !now.Before(w.bucketEnd) {
+		w.closeBucket()
+	}
+}
+
+// closeBucket empties the current bucket holding it for notification if
+// it received a live value.  Buckets made up only of historical values are
+// discarded as they were delivered before the store was last closed.
+func (w *window) closeBucket() {
+	if w.bucketLive {
+		w.completed = w.bucket()
+	}
+
+	w.count = 0
+	w.total = 0
+	w.bucketLive = false
+}
+
+// bucket summarizes the current bucket.
+func (w *window) bucket() *Bucket {
+	return &Bucket{
+		DatKey: w.datKey,
+		WinKey: w.winKey,
+		Start:  w.bucketEnd.Add(-w.period),
+		End:    w.bucketEnd,
+		Count:  w.count,
+		Avg:    w.avg,
+		Min:    w.bucketMin,
+		Max:    w.bucketMax,
+	}
+}
+
+// stageBuckets stages the notification of any buckets of the key's tumbling
+// windows completed by the last value.
+func (fs *fileStore) stageBuckets(wdb *winDB) {
+	for _, winKey := range wdb.winKeys {
+		fs.stageBucket(wdb.windows[winKey])
+	}
+}
+
+// stageBucket stages the notification of the window's completed bucket.
+func (fs *fileStore) stageBucket(w *window) {
+	if w.completed != nil {
+		callback, completed := w.bucketNotify, *w.completed
+		w.completed = nil
+		fs.stage(func() {
+			callback(completed)
+		})
+	}
+}
+
+// checkBuckets completes the buckets of all tumbling windows that have
+// ended by the store clock.
+func (fs *fileStore) checkBuckets() {
+	var now time.Time
+
+	fs.completeBuckets(func(w *window) {
+		if now.IsZero() {
+			now = fs.ts()
+		}
+
+		w.completeBucket(now)
+	})
+}
+
+// flushBuckets delivers the open buckets of all tumbling windows as they
+// stand leaving them open to be restored when the store is reopened.
+func (fs *fileStore) flushBuckets() {
+	fs.completeBuckets(func(w *window) {
+		if w.bucketLive {
+			w.completed = w.bucket()
+		}
+	})
+}
+
+// completeBuckets applies complete to each tumbling window holding values
+// notifying any completed buckets after the lock is released.
+func (fs *fileStore) completeBuckets(complete func(*window)) {
+	defer fs.dispatch()
+
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	for _, datKey := range slices.Sorted(maps.Keys(fs.winDB)) {
+		wdb := fs.winDB[datKey]
+		for _, winKey := range wdb.winKeys {
+			w := wdb.windows[winKey]
+			if w.kind != windowTumbling || w.count == 0 {
+				continue
+			}
+
+			complete(w)
+			fs.stageBucket(w)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestWindowWindows_Tumbling(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	var buckets []Bucket
+
+	notify := func(b Bucket) {
+		buckets = append(buckets, b)
+	}
+
+	wdb := newWinDB("datKey1")
+	chk.Err(
+		wdb.addTumblingWindow("bad", time.Hour*7, notify),
+		ErrInvalidBucketPeriod.Error(),
+	)
+	chk.Err(
+		wdb.addTumblingWindow("bad", 0, notify),
+		ErrInvalidBucketPeriod.Error(),
+	)
+	chk.Err(
+		wdb.addTumblingWindow("bad", time.Minute, nil),
+		ErrNilNotifyFunc.Error(),
+	)
+	chk.NoErr(wdb.addTumblingWindow("q", time.Minute*15, notify))
+
+	_, err := wdb.getAvg("q")
+	chk.Err(err, ErrNoWinData.Error())
+
+	// Completed buckets are staged for the store to deliver once unlocked.
+	var fStore fileStore
+
+	addValue := func(timestamp time.Time, value float64) {
+		wdb.addValue(timestamp, value)
+		fStore.stageBuckets(wdb)
+		fStore.dispatch()
+	}
+
+	start := time.Date(2020, time.January, 1, 2, 0, 0, 0, time.Local)
+
+	for _, offset := range []time.Duration{
+		time.Minute * 3,  // First bucket 02:00-02:15.
+		time.Minute * 14, // Still first bucket.
+		time.Minute * 15, // Second bucket 02:15-02:30.
+		time.Minute * 61, // Skips empty buckets to 03:00-03:15.
+	} {
+		addValue(start.Add(offset), float64(offset/time.Minute))
+	}
+
+	chk.Int(len(buckets), 2)
+	chk.Str(buckets[0].WinKey, "q")
+	chk.True(buckets[0].Start.Equal(start))
+	chk.True(buckets[0].End.Equal(start.Add(time.Minute * 15)))
+	chk.Uint64(buckets[0].Count, 2)
+	chk.Float64(buckets[0].Avg, 8.5, 0)
+	chk.Float64(buckets[0].Min, 3, 0)
+	chk.Float64(buckets[0].Max, 14, 0)
+	chk.True(buckets[1].Start.Equal(start.Add(time.Minute * 15)))
+	chk.Uint64(buckets[1].Count, 1)
+	chk.Float64(buckets[1].Avg, 15, 0)
+
+	// The current bucket is still open.
+	got, err := wdb.getAvg("q")
+	chk.NoErr(err)
+	chk.Float64(got, 61, 0)
+
+	_, err = wdb.getStat("q", (*window).getMin)
+	chk.Err(err, ErrWindowKind.Error())
+
+	// Historical values complete buckets without notifications.
+	wdb.loadValue(start.Add(time.Minute*75), 1)
+	addValue(start.Add(time.Minute*76), 2)
+	chk.Int(len(buckets), 2)
+}
+
+func TestWindowWindows_AddTumblingWindow(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second*20,
+	)
+
+	var buckets []Bucket
+
+	addWindows := func(fStore *fileStore) {
+		fStore.SetCheckpointInterval(time.Hour)
+		chk.NoErr(fStore.AddWindow("key1", "w1", time.Second))
+		chk.NoErr(
+			fStore.AddTumblingWindow("key1", "min", time.Minute,
+				func(b Bucket) {
+					buckets = append(buckets, b)
+				},
+			),
+		)
+	}
+
+	addWindows(fStore)
+	chk.NoErr(fStore.Open())
+
+	for _, v := range []float64{1, 2, 3, 4} {
+		chk.NoErr(fStore.update("key1", "v", v))
+	}
+
+	// Back-filled with the values of the current bucket.
+	chk.NoErr(
+		fStore.AddTumblingWindow("key1", "another", time.Hour,
+			func(Bucket) {},
+		),
+	)
+
+	count, err := fStore.WindowCount("key1", "another")
+	chk.NoErr(err)
+	chk.Uint64(count, 1)
+	chk.NoErr(fStore.RemoveWindow("key1", "another"))
+
+	chk.Int(len(buckets), 1)
+	chk.Uint64(buckets[0].Count, 3)
+	chk.Float64(buckets[0].Avg, 2, 0)
+	chk.NoErr(fStore.Close())
+
+	// The open bucket is restored from the checkpoint without any
+	// notifications during the reload.
+	buckets = nil
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = chk.ClockNext
+	addWindows(fStore)
+	chk.NoErr(fStore.Open())
+
+	chk.Int(len(buckets), 0)
+
+	count, err = fStore.WindowCount("key1", "min")
+	chk.NoErr(err)
+	chk.Uint64(count, 1)
+
+	for _, v := range []float64{5, 6, 7} {
+		chk.NoErr(fStore.update("key1", "v", v))
+	}
+
+	chk.Int(len(buckets), 1)
+	chk.Uint64(buckets[0].Count, 3)
+	chk.Float64(buckets[0].Avg, 5, 0)
+	chk.Float64(buckets[0].Min, 4, 0)
+	chk.Float64(buckets[0].Max, 6, 0)
+	chk.NoErr(fStore.Close())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`checkpoint restored from: {{dir}}/{{file}}.ckp`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestWindowWindows_TumblingBucketEnd(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 0, 0, 0, time.Local),
+	)
+
+	now := time.Date(2000, 5, 15, 12, 0, 10, 0, time.Local)
+	clock := func() time.Time {
+		return now
+	}
+	fStore.ts = clock
+
+	var buckets []Bucket
+
+	addWindow := func(fStore *fileStore) {
+		chk.NoErr(
+			fStore.AddTumblingWindow("key1", "min", time.Minute,
+				func(b Bucket) {
+					buckets = append(buckets, b)
+				},
+			),
+		)
+	}
+
+	addWindow(fStore)
+	chk.NoErr(fStore.Open())
+
+	chk.NoErr(fStore.update("key1", "v", 1))
+	now = now.Add(time.Second * 20)
+	chk.NoErr(fStore.update("key1", "v", 3))
+
+	// Not yet ended.
+	now = now.Add(time.Second * 29)
+	fStore.checkBuckets()
+	chk.Int(len(buckets), 0)
+
+	// Completed by the store clock without any further values.
+	now = now.Add(time.Second)
+	fStore.checkBuckets()
+	fStore.checkBuckets() // Only reported once.
+	chk.Int(len(buckets), 1)
+	chk.True(buckets[0].End.Equal(now))
+	chk.Uint64(buckets[0].Count, 2)
+	chk.Float64(buckets[0].Avg, 2, 0)
+
+	_, err := fStore.WindowAverage("key1", "min")
+	chk.Err(err, ErrNoWinData.Error())
+
+	// The open bucket is delivered on Close.
+	now = now.Add(time.Second * 5)
+	chk.NoErr(fStore.update("key1", "v", 5))
+	chk.NoErr(fStore.Close())
+	chk.Int(len(buckets), 2)
+	chk.Uint64(buckets[1].Count, 1)
+	chk.Float64(buckets[1].Avg, 5, 0)
+
+	// A bucket holding only historical values is not delivered again.
+	buckets = nil
+	fStore = newFileStore(dirName, filename)
+	fStore.ts = clock
+	addWindow(fStore)
+	chk.NoErr(fStore.Open())
+
+	now = now.Add(time.Minute)
+	fStore.checkBuckets()
+	chk.NoErr(fStore.Close())
+	chk.Int(len(buckets), 0)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestWindowWindows_TumblingCallbackReads(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second*20,
+	)
+
+	var averages []float64
+
+	// The callback reads the store after the update has released it.
+	chk.NoErr(
+		fStore.AddTumblingWindow("key1", "min", time.Minute,
+			func(b Bucket) {
+				avg, err := fStore.WindowAverage(b.DatKey, b.WinKey)
+				chk.NoErr(err)
+
+				averages = append(averages, avg)
+			},
+		),
+	)
+	chk.NoErr(fStore.Open())
+
+	finished := make(chan struct{})
+
+	go func() {
+		defer close(finished)
+
+		for _, v := range []float64{1, 2, 3, 4} {
+			chk.NoErr(fStore.update("key1", "v", v))
+		}
+
+		chk.NoErr(fStore.Close())
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(time.Second * 5):
+		t.Fatal("bucket callback deadlocked")
+	}
+
+	// The completed bucket is reported with the new bucket open.
+	chk.Float64Slice(averages, []float64{4, 4}, 0)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
@@ -0,0 +1,200 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"time"
+)
+
+// AddValueThreshold provides a monitor of each raw value of the specified
+// key as it is updated.  Value thresholds are kept apart from the key's
+// windows and are managed by RemoveValueThreshold, UpdateValueThreshold,
+// SetValueThresholdDeadband, SetValueThresholdDwell and ValueThresholds.
+// The window key passed to the notify function is empty.
+func (fs *fileStore) AddValueThreshold(datKey string,
+	lowCritical, lowWarning, highWarning, highCritical float64,
+	notifyFunc ThresholdNotifyFunc,
+) error {
+	defer fs.dispatch()
+
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	winDB, ok := fs.winDB[datKey]
+	if !ok {
+		winDB = newWinDB(datKey)
+		fs.winDB[datKey] = winDB
+	}
+
+	if fs.dispatcher != nil && notifyFunc != nil {
+		notifyFunc = fs.dispatcher.wrap(notifyFunc)
+	}
+
+	err := winDB.addValueThreshold(
+		lowCritical, lowWarning, highWarning, highCritical, notifyFunc,
+	)
+	if err == nil {
+		fs.activateThreshold(winDB.values)
+	}
+
+	return err
+}
+
+// RemoveValueThreshold discards a key's value threshold identified by the
+// order it was added (starting at zero).  Later thresholds move down to
+// fill its place.
+func (fs *fileStore) RemoveValueThreshold(datKey string, index int) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	w, err := fs.valueWindow(datKey)
+	if err != nil {
+		return err
+	}
+
+	return w.removeThreshold(index)
+}
+
+// UpdateValueThreshold replaces the limits of a key's value threshold
+// identified by the order it was added (starting at zero).  The threshold is
+// checked immediately against the key's last value notifying any change.
+func (fs *fileStore) UpdateValueThreshold(datKey string, index int,
+	lowCritical, lowWarning, highWarning, highCritical float64,
+) error {
+	return fs.updateValueThreshold(datKey, index,
+		func(t *threshold) error {
+			return t.setLimits(
+				lowCritical, lowWarning, highWarning, highCritical,
+			)
+		},
+	)
+}
+
+// SetValueThresholdDeadband sets the deadband of a key's value threshold
+// identified by the order it was added (starting at zero).  See
+// SetWindowThresholdDeadband.
+func (fs *fileStore) SetValueThresholdDeadband(
+	datKey string, index int, deadband float64,
+) error {
+	if deadband < 0 {
+		return ErrInvalidDeadband
+	}
+
+	return fs.updateValueThreshold(datKey, index,
+		func(t *threshold) error {
+			t.deadband = deadband
+
+			return nil
+		},
+	)
+}
+
+// SetValueThresholdDwell sets the dwell of a key's value threshold
+// identified by the order it was added (starting at zero).  See
+// SetWindowThresholdDwell.
+func (fs *fileStore) SetValueThresholdDwell(
+	datKey string, index int, dwell time.Duration,
+) error {
+	if dwell < 0 {
+		return ErrInvalidDwell
+	}
+
+	return fs.updateValueThreshold(datKey, index,
+		func(t *threshold) error {
+			t.dwell = dwell
+
+			return nil
+		},
+	)
+}
+
+// ValueThresholds describes the key's value thresholds in the order they
+// were added.
+func (fs *fileStore) ValueThresholds(datKey string) ([]ThresholdInfo, error) {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return nil, ErrUnknownDatKey
+	}
+
+	if dw.values == nil {
+		return []ThresholdInfo{}, nil
+	}
+
+	return dw.values.thresholdInfos(), nil
+}
+
+// updateValueThreshold applies a change to the indexed value threshold of a
+// key.
+func (fs *fileStore) updateValueThreshold(
+	datKey string, index int, change func(*threshold) error,
+) error {
+	defer fs.dispatch()
+
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	w, err := fs.valueWindow(datKey)
+	if err != nil {
+		return err
+	}
+
+	return w.updateThreshold(index, change)
+}
+
+// valueWindow returns the window holding the key's value thresholds.
+func (fs *fileStore) valueWindow(datKey string) (*window, error) {
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return nil, ErrUnknownDatKey
+	}
+
+	if dw.values == nil {
+		return nil, ErrUnknownThreshold
+	}
+
+	return dw.values, nil
+}
+
+// addValueThreshold adds a threshold to the key's raw values.  They are
+// held by an unlisted window of the single most recent sample created with
+// the first value threshold.
+func (wdb *winDB) addValueThreshold(
+	lowCritical, lowWarning, highWarning, highCritical float64,
+	notifyFunc ThresholdNotifyFunc,
+) error {
+	if wdb.values == nil {
+		values := newWindow(wdb.datKey, "", 0)
+		values.values = true
+		values.kind = windowCount
+		values.samples = 1
+
+		for e := wdb.oldestEntry; e != nil; e = e.prev {
+			values.load(e)
+		}
+
+		wdb.values = values
+	}
+
+	return wdb.values.addThreshold(
+		lowCritical, lowWarning, highWarning, highCritical, notifyFunc,
+	)
+}
@@ -0,0 +1,152 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+func TestValueThreshold_Update(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	notify := func(d, k string, f, t ThresholdReason, v float64) {
+		log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
+			d, k, f, t, v,
+		)
+	}
+
+	chk.NoErr(fStore.AddWindow("key1", "win1", time.Minute))
+	chk.NoErr(fStore.AddWindowThreshold("key1", "win1", 1, 2, 7, 8, notify))
+	chk.NoErr(fStore.AddValueThreshold("key1", 1, 2, 7, 8, notify))
+	chk.Err(
+		fStore.AddValueThreshold("key1", 2, 1, 7, 8, notify),
+		ErrInvalidThresholdOrder.Error(),
+	)
+	chk.Err(
+		fStore.AddValueThreshold("key1", 1, 2, 7, 8, nil),
+		ErrNilNotifyFunc.Error(),
+	)
+	// A window with an empty key is distinct from the value thresholds.
+	chk.NoErr(fStore.AddWindow("key1", "", time.Minute))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	// A single high value is reported although the average stays normal.
+	for _, v := range []float64{3, 9, 3} {
+		chk.NoErr(fStore.update("key1", "v", v))
+	}
+
+	thresholds, err := fStore.ValueThresholds("key1")
+	chk.NoErr(err)
+	chk.Int(len(thresholds), 1)
+	chk.Str(thresholds[0].Reason.String(), "Normal")
+
+	thresholds, err = fStore.Thresholds("key1", "")
+	chk.NoErr(err)
+	chk.Int(len(thresholds), 0)
+
+	_, err = fStore.ValueThresholds("key2")
+	chk.Err(err, ErrUnknownDatKey.Error())
+
+	chk.Err(
+		fStore.UpdateValueThreshold("key1", 1, 4, 5, 7, 8),
+		ErrUnknownThreshold.Error(),
+	)
+	chk.Err(
+		fStore.SetValueThresholdDeadband("key1", 0, -1),
+		ErrInvalidDeadband.Error(),
+	)
+	chk.Err(
+		fStore.SetValueThresholdDwell("key1", 0, -1),
+		ErrInvalidDwell.Error(),
+	)
+	chk.NoErr(fStore.SetValueThresholdDeadband("key1", 0, 0))
+	chk.NoErr(fStore.SetValueThresholdDwell("key1", 0, 0))
+
+	// Changing the limits rechecks the last value.
+	chk.NoErr(fStore.UpdateValueThreshold("key1", 0, 4, 5, 7, 8))
+
+	// Added to a key already holding values.
+	chk.NoErr(fStore.update("key2", "v", 9))
+	chk.NoErr(fStore.AddValueThreshold("key2", 1, 2, 7, 8, notify))
+
+	chk.NoErr(fStore.RemoveValueThreshold("key1", 0))
+	chk.Err(
+		fStore.RemoveValueThreshold("key1", 0),
+		ErrUnknownThreshold.Error(),
+	)
+	chk.Err(
+		fStore.RemoveValueThreshold("key3", 0),
+		ErrUnknownDatKey.Error(),
+	)
+	chk.NoErr(fStore.update("key1", "v", 29)) // Only the window.
+
+	chk.NoErr(fStore.Delete("key2"))
+	chk.NoErr(fStore.update("key2", "v", 3))
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Threshold("key1","win1"),from: Unknown, to: Normal, value: 3`,
+		`Threshold("key1",""),from: Unknown, to: Normal, value: 3`,
+		`Threshold("key1",""),from: Normal, to: High Critical, value: 9`,
+		`Threshold("key1",""),from: High Critical, to: Normal, value: 3`,
+		`Threshold("key1",""),from: Normal, to: Low Critical, value: 3`,
+		`Threshold("key2",""),from: Unknown, to: High Critical, value: 9`,
+		`Threshold("key1","win1"),from: Normal, to: High Critical,`+
+			` value: 11`,
+		`Threshold("key2",""),from: High Critical, to: Normal, value: 3`,
+	)
+}
+
+func TestValueThreshold_Bool(t *testing.T) {
+	chk := sztest.CaptureNothing(t)
+	defer chk.Release()
+
+	boolStore := NewBool(chk.CreateTmpDir(), "bool")
+
+	chk.Err(
+		boolStore.AddValueThreshold("key1", 0, 0, 0, 2,
+			func(_, _ string, _, _ ThresholdReason, _ float64) {},
+		),
+		ErrInvalidBoolThreshold.Error(),
+	)
+	chk.NoErr(
+		boolStore.AddValueThreshold("key1", 0, 0, 1, 1,
+			func(_, _ string, _, _ ThresholdReason, _ float64) {},
+		),
+	)
+	chk.Err(
+		boolStore.UpdateValueThreshold("key1", 0, 0, 0, 1, 2),
+		ErrInvalidBoolThreshold.Error(),
+	)
+	chk.NoErr(boolStore.UpdateValueThreshold("key1", 0, 0, 0, 1, 1))
+}
@@ -0,0 +1,210 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"iter"
+	"time"
+)
+
+// Codec defines the conversions required to store values of type T.  A
+// codec must be able to decode everything it encodes.
+type Codec[T any] interface {
+	// Name identifies the type in log messages (ie: parse<Name>: ...).
+	Name() string
+	// Encode returns the string written to the data file.
+	Encode(value T) string
+	// Decode returns the value represented by a string read from the data
+	// file.
+	Decode(raw string) (T, error)
+	// Float returns the value to be included in the key's windows.
+	Float(value T) float64
+}
+
+// WStore contains and links the underlying Storage implementation
+// and its associated numeric window for values of type T.
+type WStore[T any] struct {
+	*fileStore
+	codec Codec[T]
+}
+
+// New returns a new Store object for values encoded by the provided codec.
+func New[T any](dirName, filenameRoot string, codec Codec[T]) *WStore[T] {
+	store := newFileStore(dirName, filenameRoot)
+	store.toFloat = func(raw string) (float64, bool) {
+		value, err := codec.Decode(raw)
+		if err != nil {
+			return 0, false
+		}
+
+		return codec.Float(value), true
+	}
+
+	return &WStore[T]{
+		fileStore: store,
+		codec:     codec,
+	}
+}
+
+func (s *WStore[T]) parse(raw string) (T, bool) {
+	value, err := s.codec.Decode(raw)
+	if err != nil {
+		var zero T
+
+		s.logMsg("parse" + s.codec.Name() + ": " + err.Error())
+
+		return zero, false
+	}
+
+	return value, true
+}
+
+// Update creates or updates a new key value.
+func (s *WStore[T]) Update(key string, value T) error {
+	return s.fileStore.update(
+		key, s.codec.Encode(value), s.codec.Float(value),
+	)
+}
+
+// Get returns the most recent value for the associated key.
+func (s *WStore[T]) Get(key string) (time.Time, T, bool) {
+	ts, v, ok := s.fileStore.get(key)
+	if ok {
+		value, ok := s.parse(v)
+		if ok {
+			return ts, value, true
+		}
+	}
+
+	var zero T
+
+	return time.Time{}, zero, false
+}
+
+// GetHistoryDays returns all values made over the specified number of days.
+// A zero represent only the current day.
+func (s *WStore[T]) GetHistoryDays(
+	key string, days uint,
+) ([]time.Time, []T) {
+	return s.collect(func(add func(Action, time.Time, string)) {
+		s.fileStore.getHistoryDays(key, days, add)
+	})
+}
+
+// GetHistoryRange returns all values made between from and to inclusive.
+func (s *WStore[T]) GetHistoryRange(
+	key string, from, to time.Time,
+) ([]time.Time, []T) {
+	return s.collect(func(add func(Action, time.Time, string)) {
+		s.fileStore.getHistoryRange(key, from, to, add)
+	})
+}
+
+// HistoryDays returns an iterator over the values made over the specified
+// number of days.  A zero represent only the current day.  Values are read
+// one file at a time with the store's read lock released between files so
+// the loop body may update the store.  As values are yielded as they are
+// read any preceding a delete are not discarded.
+func (s *WStore[T]) HistoryDays(
+	key string, days uint,
+) iter.Seq2[time.Time, T] {
+	return func(yield func(time.Time, T) bool) {
+		s.stream(
+			key, s.fileStore.daysMinStamp(days), "", time.Time{}, time.Time{},
+			yield,
+		)
+	}
+}
+
+// HistoryRange returns an iterator over the values made between from and to
+// inclusive.  It streams values in the same manner as HistoryDays.
+func (s *WStore[T]) HistoryRange(
+	key string, from, to time.Time,
+) iter.Seq2[time.Time, T] {
+	return func(yield func(time.Time, T) bool) {
+		if to.Before(from) {
+			return
+		}
+
+		minStamp, maxStamp := s.fileStore.rangeStamps(from, to)
+		s.stream(key, minStamp, maxStamp, from, to, yield)
+	}
+}
+
+// stream yields the parsed update values for a key a file at a time.
+func (s *WStore[T]) stream(
+	key, minStamp, maxStamp string,
+	from, to time.Time,
+	yield func(time.Time, T) bool,
+) {
+	var (
+		timestamps []time.Time
+		values     []T
+	)
+
+	s.fileStore.scanHistory(key, minStamp, maxStamp, from, to,
+		func(a Action, timestamp time.Time, raw string) {
+			if a == ActionUpdate {
+				vParsed, ok := s.parse(raw)
+				if ok {
+					timestamps = append(timestamps, timestamp)
+					values = append(values, vParsed)
+				}
+			}
+		},
+		func() bool {
+			for i, timestamp := range timestamps {
+				if !yield(timestamp, values[i]) {
+					return false
+				}
+			}
+
+			timestamps = timestamps[:0]
+			values = values[:0]
+
+			return true
+		},
+	)
+}
+
+// collect accumulates the parsed values passed by the supplied history
+// function.  A delete action discards all values collected so far.
+func (s *WStore[T]) collect(
+	history func(add func(Action, time.Time, string)),
+) ([]time.Time, []T) {
+	var (
+		timestamps []time.Time
+		values     []T
+	)
+
+	history(func(a Action, timestamp time.Time, raw string) {
+		if a == ActionDelete {
+			timestamps = nil
+			values = nil
+		} else {
+			vParsed, ok := s.parse(raw)
+			if ok {
+				timestamps = append(timestamps, timestamp)
+				values = append(values, vParsed)
+			}
+		}
+	})
+
+	return timestamps, values
+}
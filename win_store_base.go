@@ -21,8 +21,11 @@ package szstore
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,8 +40,6 @@ const (
 	fmtTimeStamp           = "20060102150405.000000000"
 	fmtDateStamp           = "20060102"
 	fileExtension          = ".dat"
-	rangeErrPrefix         = "range: "
-	syntaxErrPrefix        = "syntax: "
 	base10                 = 10
 )
 
@@ -52,12 +53,22 @@ type dataPoint struct {
 type fileStore struct {
 	rwMutex sync.RWMutex
 
-	opened          bool
-	filenameRoot    string
-	dirName         string
-	currentFile     *os.File
-	currentFileDate string
-	fileHistory     []string
+	opened           bool
+	filenameRoot     string
+	dirName          string
+	rotation         Rotation
+	maxFileSize      int64
+	retentionDays    uint
+	retentionSize    int64
+	archive          ArchiveFunc
+	compress         bool
+	compressing      sync.WaitGroup
+	currentFile      *os.File
+	currentFileStamp string
+	currentFileSeq   int
+	currentFileSize  int64
+	currentFileVer   int
+	fileHistory      []string
 
 	// Most recent Values.
 	data map[string]*dataPoint
@@ -65,10 +76,49 @@ type fileStore struct {
 	// Windows.
 	winDB map[string]*winDB
 
+	// Window history replay.
+	toFloat          func(string) (float64, bool)
+	replayThresholds bool
+
+	// Asynchronous threshold notifications.
+	dispatcher *dispatcher
+
+	// Bucket and stale callbacks staged while the store is locked.
+	stagedMu sync.Mutex
+	staged   []func()
+
+	// Threshold journal.
+	journal          bool
+	journalFile      *os.File
+	journalFileStamp string
+	journalHistory   []string
+
+	// Stale thresholds.
+	stale map[string][]*staleThreshold
+
+	// Background checks of stale thresholds and tumbling windows.
+	watchTicker   *time.Ticker
+	watchInterval time.Duration
+	watchStop     chan struct{}
+	watching      sync.WaitGroup
+
+	// Durability.
+	durability   Durability
+	syncInterval time.Duration
+	syncRecords  uint
+	pendingSync  uint
+	syncTimer    *time.Timer
+	recovery     RecoveryReport
+
+	// Checkpoints.
+	checkpointInterval time.Duration
+	lastCheckpoint     time.Time
+
 	// File record loading.
 	fName    string
 	fLine    string
 	fLineNum uint
+	fVersion int
 
 	ts func() time.Time
 }
@@ -79,8 +129,11 @@ func newFileStore(dirName, filenameRoot string) *fileStore {
 	fStore.opened = false
 	fStore.dirName = dirName
 	fStore.filenameRoot = filenameRoot
+	fStore.rotation = RotateDaily
+	fStore.durability = DurabilityNone
 	fStore.data = make(map[string]*dataPoint)
 	fStore.winDB = make(map[string]*winDB)
+	fStore.stale = make(map[string][]*staleThreshold)
 	fStore.ts = time.Now // Default
 
 	return fStore
@@ -100,6 +153,12 @@ func (fs *fileStore) logMsg(msg string) bool {
 
 // open opens (or creates) a fileStore object.
 func (fs *fileStore) Open() error {
+	if fs.dispatcher != nil {
+		fs.dispatcher.start()
+	}
+
+	defer fs.dispatch()
+
 	fs.rwMutex.Lock()
 	defer fs.rwMutex.Unlock()
 
@@ -118,14 +177,34 @@ func (fs *fileStore) Open() error {
 	}
 
 	for _, fileInf := range allFiles {
-		if strings.HasPrefix(fileInf.Name(), fs.filenameRoot) {
+		if _, _, ok := fs.splitFileName(fileInf.Name()); ok {
 			fs.fileHistory = append(fs.fileHistory, fileInf.Name())
 		}
 	}
 
+	fs.fileHistory = catalogCompressed(fs.fileHistory)
+
+	if len(fs.fileHistory) > 0 && fs.retentionSet() {
+		fs.applyRetention(fs.ts())
+	}
+
+	if len(fs.fileHistory) > 0 && fs.compress {
+		fs.compressOld()
+	}
+
 	if len(fs.fileHistory) > 0 {
-		for _, n := range fs.fileHistory {
-			fs.loadHistory(fs.dirName + string(os.PathSeparator) + n)
+		fs.recoverTornRecord()
+	}
+
+	if len(fs.fileHistory) > 0 {
+		start, offset := 0, int64(0)
+		if fs.checkpointInterval > 0 {
+			start, offset = fs.restoreCheckpoint()
+		}
+
+		for _, n := range fs.fileHistory[start:] {
+			fs.loadHistory(fs.filePath(n), offset)
+			offset = 0
 		}
 
 		startingFilePath = fs.dirName +
@@ -134,7 +213,7 @@ func (fs *fileStore) Open() error {
 		log.Print("starting path retrieved as: " + startingFilePath)
 		err = fs.openFile(startingFilePath)
 	} else {
-		startingFilePath = fs.generateFilePath(fs.ts())
+		startingFilePath = fs.generateFilePath(fs.rotation.stamp(fs.ts()), 0)
 		log.Print("starting path generated as: " + startingFilePath)
 
 		err = fs.openFile(startingFilePath)
@@ -143,29 +222,121 @@ func (fs *fileStore) Open() error {
 
 			fi, err = os.Stat(startingFilePath)
 			if err == nil {
-				fs.fileHistory = append(fs.fileHistory, fi.Name())
+				fs.catalogFile(fi.Name())
 			}
 		}
 	}
 
+	if err == nil && fs.journal {
+		err = fs.openJournal()
+	}
+
 	if err == nil {
 		fs.opened = true
+
+		if len(fs.stale) > 0 {
+			fs.startStale(fs.ts())
+		}
+
+		fs.startWatch()
 	}
 
 	return err //nolint:wrapcheck // Ok.
 }
 
-func (fs *fileStore) generateFilePath(t time.Time) string {
-	return fs.dirName +
-		string(os.PathSeparator) +
-		fs.filenameRoot + "_" +
-		t.Format(fmtDateStamp) + fileExtension
+// SetRotation sets the period covered by each data file.  The default is
+// RotateDaily.  The rotation should not be changed for an existing store as
+// files named for a different rotation (each stamps its files differently)
+// are ignored.
+func (fs *fileStore) SetRotation(rotation Rotation) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if fs.opened {
+		return ErrOpenedRotation
+	}
+
+	if !rotation.IsOK() {
+		return ErrInvalidRotation
+	}
+
+	fs.rotation = rotation
+
+	return nil
+}
+
+// SetMaxFileSize limits the number of bytes written to each data file.  Once
+// exceeded a new file is started for the same period with an increasing
+// sequence suffix.  A zero (the default) disables the limit.
+func (fs *fileStore) SetMaxFileSize(maxBytes int64) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	if fs.opened {
+		return ErrOpenedRotation
+	}
+
+	fs.maxFileSize = max(maxBytes, 0)
+
+	return nil
+}
+
+func (fs *fileStore) generateFilePath(stamp string, seq int) string {
+	fName := fs.filenameRoot + "_" + stamp
+
+	if seq > 0 {
+		fName += "_" + fmt.Sprintf(fmtSequence, seq)
+	}
+
+	return fs.filePath(fName + fileExtension)
+}
+
+// filePath returns the full path of a file in the store's directory.
+func (fs *fileStore) filePath(fName string) string {
+	return fs.dirName + string(os.PathSeparator) + fName
+}
+
+// splitFileName returns the period stamp and sequence number from a data
+// file name (or path) belonging to this store.
+func (fs *fileStore) splitFileName(fName string) (string, int, bool) {
+	fName = filepath.Base(fName)
+
+	middle, ok := strings.CutPrefix(fName, fs.filenameRoot+"_")
+	if ok {
+		middle = strings.TrimSuffix(middle, compressedExtension)
+		middle, ok = strings.CutSuffix(middle, fileExtension)
+	}
+
+	if !ok {
+		return "", 0, false
+	}
+
+	stamp, rawSeq, hasSeq := strings.Cut(middle, "_")
+	if !fs.rotation.validStamp(stamp) {
+		return "", 0, false
+	}
+
+	seq := 0
+
+	if hasSeq {
+		var err error
+
+		seq, err = strconv.Atoi(rawSeq)
+		if err != nil || seq < 1 || len(rawSeq) != sequenceWidth {
+			return "", 0, false
+		}
+	}
+
+	return stamp, seq, true
 }
 
 func (fs *fileStore) openFile(fPath string) error {
 	if fs.currentFile != nil {
 		closeAndLogIfError(fs.currentFile)
-		fs.currentFileDate = ""
+		fs.currentFileStamp = ""
+		fs.currentFileSeq = 0
+		fs.currentFileSize = 0
+		fs.currentFileVer = 0
 		fs.currentFile = nil
 	}
 
@@ -173,13 +344,46 @@ func (fs *fileStore) openFile(fPath string) error {
 		fPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, defaultFilePermissions,
 	)
 	if err == nil {
-		fs.currentFileDate = fPath[len(fPath)-12 : len(fPath)-4]
+		var fileInfo os.FileInfo
+
+		fileInfo, err = f.Stat()
+		if err == nil {
+			fs.currentFileSize = fileInfo.Size()
+			err = fs.setFileVersion(f, fPath)
+		}
+
+		fs.currentFileStamp, fs.currentFileSeq, _ = fs.splitFileName(fPath)
 		fs.currentFile = f
 	}
 
 	return err //nolint:wrapcheck // Ok.
 }
 
+// setFileVersion writes the header to a new file or determines the record
+// version of an existing file being appended to.
+func (fs *fileStore) setFileVersion(f *os.File, fPath string) error {
+	if fs.currentFileSize == 0 {
+		n, err := f.WriteString(fileHeader())
+		fs.currentFileSize = int64(n)
+		fs.currentFileVer = recordVersion
+
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	r, err := os.Open(fPath) //nolint:gosec // Ok.
+	if err == nil {
+		defer closeAndLogIfError(r)
+
+		fs.currentFileVer, err = readFileVersion(bufio.NewScanner(r))
+	}
+
+	if err != nil {
+		log.Print("setFileVersion: " + err.Error())
+	}
+
+	return nil
+}
+
 func (fs *fileStore) loadHistoryFile(
 	fName string, scanner *bufio.Scanner,
 ) error {
@@ -187,6 +391,14 @@ func (fs *fileStore) loadHistoryFile(
 		fs.fLine = scanner.Text()
 		fs.fLineNum++
 
+		if fs.readHeader(fs.fLine) {
+			continue
+		}
+
+		if fs.fVersion == 0 {
+			break
+		}
+
 		timestamp, action, datKey, value, ok := fs.splitRecord(fName, fs.fLine)
 		if ok {
 			if action == ActionDelete {
@@ -196,8 +408,8 @@ func (fs *fileStore) loadHistoryFile(
 				}
 
 				delete(fs.data, datKey)
-			} else {
-				fs.load(timestamp, datKey, value)
+			} else if fs.load(timestamp, datKey, value) {
+				fs.loadWindows(timestamp, datKey, value)
 			}
 		}
 	}
@@ -205,19 +417,32 @@ func (fs *fileStore) loadHistoryFile(
 	return scanner.Err() //nolint:wrapcheck // Ok.
 }
 
-func (fs *fileStore) loadHistory(fName string) {
+// loadHistory replays the records in the data file starting from the
+// provided byte offset.
+func (fs *fileStore) loadHistory(fName string, offset int64) {
 	defer func() {
 		fs.fName = ""
 		fs.fLineNum = 0
 		fs.fLine = ""
+		fs.fVersion = 0
 	}()
 
 	fs.fName = fName
 	fs.fLineNum = 0
 
-	f, err := os.Open(fName) //nolint:gosec // Ok.
+	f, err := openData(fName)
 	if err == nil {
-		err = fs.loadHistoryFile(fName, bufio.NewScanner(f))
+		defer closeAndLogIfError(f)
+
+		r := bufio.NewReader(f)
+
+		if offset > 0 {
+			err = fs.skipTo(r, offset)
+		}
+
+		if err == nil {
+			err = fs.loadHistoryFile(fName, bufio.NewScanner(r))
+		}
 	}
 
 	if err != nil {
@@ -225,6 +450,19 @@ func (fs *fileStore) loadHistory(fName string) {
 	}
 }
 
+// skipTo discards the records before the offset retaining the record version
+// from the file's header.
+func (fs *fileStore) skipTo(r *bufio.Reader, offset int64) error {
+	line, err := r.ReadString('\n')
+	if err == nil {
+		fs.fLineNum = 1
+		fs.readHeader(strings.TrimSuffix(line, "\n"))
+		_, err = io.CopyN(io.Discard, r, offset-int64(len(line)))
+	}
+
+	return err //nolint:wrapcheck // Ok.
+}
+
 //nolint:funlen // Ok.
 func (fs *fileStore) splitRecord(filePath string, data string) (
 	time.Time,
@@ -274,11 +512,12 @@ func (fs *fileStore) splitRecord(filePath string, data string) (
 		)
 	}
 
-	if !strings.HasPrefix(
-		fields[0], filePath[len(filePath)-12:len(filePath)-4],
-	) {
+	fileStamp, _, _ := fs.splitFileName(filePath)
+
+	recordStamp := fs.rotation.stamp(timestamp)
+	if recordStamp != fileStamp {
 		return timestamp, action, key, value, fs.logMsg(
-			proc + "invalid date mismatch: \"" + fields[0][:8] + `"`,
+			proc + "invalid date mismatch: \"" + recordStamp + `"`,
 		)
 	}
 
@@ -294,12 +533,18 @@ func (fs *fileStore) splitRecord(filePath string, data string) (
 	}
 
 	key = fields[2]
-	value = fields[3]
+
+	value, err = decodeValue(fs.fVersion, fields[3])
+	if err != nil {
+		return timestamp, action, key, value, fs.logMsg(
+			proc + "invalid value: " + err.Error(),
+		)
+	}
 
 	return timestamp, action, key, value, true
 }
 
-func (fs *fileStore) load(timeStamp time.Time, key, value string) {
+func (fs *fileStore) load(timeStamp time.Time, key, value string) bool {
 	data, ok := fs.data[key]
 	if !ok {
 		data = new(dataPoint)
@@ -317,11 +562,35 @@ func (fs *fileStore) load(timeStamp time.Time, key, value string) {
 			),
 		)
 
-		return
+		return false
 	}
 
 	data.TS = timeStamp
 	data.Value = value
+
+	return true
+}
+
+// loadWindows replays a historical value into the data key's windows.  Old
+// entries are trimmed as newer ones are loaded leaving each window as it was
+// before the store was last closed.  Thresholds are only checked if enabled
+// with SetReplayThresholds.
+func (fs *fileStore) loadWindows(timeStamp time.Time, key, raw string) {
+	if fs.toFloat == nil {
+		return
+	}
+
+	value, ok := fs.toFloat(raw)
+	if !ok {
+		return
+	}
+
+	if fs.replayThresholds {
+		fs.winDB[key].addValue(timeStamp, value)
+		fs.stageBuckets(fs.winDB[key])
+	} else {
+		fs.winDB[key].loadValue(timeStamp, value)
+	}
 }
 
 func (fs *fileStore) writeToFile(
@@ -330,32 +599,82 @@ func (fs *fileStore) writeToFile(
 	var err error
 
 	timestamp := fs.ts()
+	stamp := fs.rotation.stamp(timestamp)
+	entry := fs.record(timestamp, action, key, value)
+	fPath := ""
+
+	switch {
+	case stamp != fs.currentFileStamp:
+		fPath = fs.generateFilePath(stamp, 0)
+	case !fs.canAppend(value):
+		fPath = fs.generateFilePath(stamp, fs.currentFileSeq+1)
+	case fs.maxFileSize > 0 &&
+		fs.currentFileSize > int64(len(fileHeader())) &&
+		fs.currentFileSize+int64(len(entry)) > fs.maxFileSize:
+		fPath = fs.generateFilePath(stamp, fs.currentFileSeq+1)
+	}
 
-	if timestamp.Format(fmtDateStamp) != fs.currentFileDate {
-		var fileInfo os.FileInfo
+	if fPath != "" {
+		err = fs.rollover(fPath, timestamp)
+		// The new file may use a different record version.
+		entry = fs.record(timestamp, action, key, value)
+	}
 
-		fPath := fs.generateFilePath(timestamp)
+	if err == nil {
+		var n int
 
-		err = fs.openFile(fPath)
-		if err == nil {
-			fileInfo, err = os.Stat(fPath)
-			if err == nil {
-				fs.fileHistory = append(fs.fileHistory, fileInfo.Name())
-			}
-		}
+		n, err = fs.currentFile.WriteString(entry)
+		fs.currentFileSize += int64(n)
 	}
 
 	if err == nil {
-		entry := fmt.Sprintf(
-			"%s|%c|%s|%s\n",
-			timestamp.Format(fmtTimeStamp), action, key, value,
-		)
-		_, err = fs.currentFile.WriteString(entry)
+		err = fs.syncIfDue()
 	}
 
 	return timestamp, err //nolint:wrapcheck // Ok.
 }
 
+// catalogFile adds a data file to the history keeping it in order.
+func (fs *fileStore) catalogFile(fName string) {
+	if !slices.Contains(fs.fileHistory, fName) {
+		fs.fileHistory = append(fs.fileHistory, fName)
+		slices.Sort(fs.fileHistory)
+	}
+}
+
+// rollover starts writing to a new data file compressing the previous file
+// and applying the retention policy as requested.
+func (fs *fileStore) rollover(fPath string, timestamp time.Time) error {
+	previous := ""
+	if fs.currentFile != nil {
+		previous = filepath.Base(fs.currentFile.Name())
+	}
+
+	err := fs.syncFile()
+	if err == nil {
+		err = fs.openFile(fPath)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fileInfo, err := os.Stat(fPath)
+	if err != nil {
+		return err //nolint:wrapcheck // Ok.
+	}
+
+	fs.catalogFile(fileInfo.Name())
+
+	if fs.compress && previous != "" && previous != fileInfo.Name() {
+		fs.compressInBackground(previous)
+	}
+
+	fs.applyRetention(timestamp)
+
+	return nil
+}
+
 // get returns the last value set for the specific key.
 func (fs *fileStore) get(datKey string) (time.Time, string, bool) {
 	fs.rwMutex.RLock()
@@ -379,38 +698,129 @@ func (fs *fileStore) getHistoryDays(
 	fs.rwMutex.RLock()
 	defer fs.rwMutex.RUnlock()
 
-	//nolint:gosec //Ok if days loses precision.
-	minFile := fs.filenameRoot +
-		"_" +
-		fs.ts().AddDate(0, 0, -1*int(days)).Format(fmtDateStamp)
-	found := false
+	minStamp := fs.daysMinStamp(days)
+
+	for _, filename := range fs.fileHistory {
+		if fs.fileInRange(filename, minStamp, "") {
+			fs.addAll(filename, datKey, time.Time{}, time.Time{}, add)
+		}
+	}
+}
+
+// getHistoryRange returns all measures made between from and to inclusive.
+// Only the files covering the range are scanned and scanning stops once a
+// record past to is encountered.
+func (fs *fileStore) getHistoryRange(
+	datKey string, from, to time.Time, add func(Action, time.Time, string),
+) {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	if to.Before(from) {
+		return
+	}
+
+	minStamp, maxStamp := fs.rangeStamps(from, to)
 
 	for _, filename := range fs.fileHistory {
-		if !found {
-			if filename >= minFile {
-				found = true
+		if fs.fileInRange(filename, minStamp, maxStamp) {
+			if fs.addAll(filename, datKey, from, to, add) {
+				return
 			}
 		}
+	}
+}
+
+// scanHistory passes the measures for a key to add one file at a time,
+// calling flush with the read lock released after each file is scanned.
+// Files outside of minStamp and maxStamp (if not empty) are skipped.
+// Scanning stops when flush returns false or a record after a non zero to
+// is found.  Each file is read under its current name as it may have been
+// compressed (or expired) since the scan began.
+func (fs *fileStore) scanHistory(
+	datKey, minStamp, maxStamp string,
+	from, to time.Time,
+	add func(Action, time.Time, string),
+	flush func() bool,
+) {
+	fs.rwMutex.RLock()
+	files := slices.Clone(fs.fileHistory)
+	fs.rwMutex.RUnlock()
+
+	for _, filename := range files {
+		if !fs.fileInRange(filename, minStamp, maxStamp) {
+			continue
+		}
+
+		fs.rwMutex.RLock()
+		passedTo := false
+
+		if current, ok := fs.historyName(filename); ok {
+			passedTo = fs.addAll(current, datKey, from, to, add)
+		}
+		fs.rwMutex.RUnlock()
 
-		if found {
-			fs.addAll(filename, datKey, add)
+		if !flush() || passedTo {
+			return
 		}
 	}
 }
 
+// fileInRange checks if the file's period stamp lies between minStamp and
+// maxStamp (if not empty) inclusive.
+func (fs *fileStore) fileInRange(
+	filename, minStamp, maxStamp string,
+) bool {
+	stamp, _, ok := fs.splitFileName(filename)
+
+	return ok && stamp >= minStamp && (maxStamp == "" || stamp <= maxStamp)
+}
+
+// daysMinStamp returns the stamp of the oldest file included in a history
+// request for the provided number of days.
+func (fs *fileStore) daysMinStamp(days uint) string {
+	return fs.daysStamp(fs.ts(), days)
+}
+
+// daysStamp returns the stamp of the file containing the start of the day
+// the provided number of days before now.
+func (fs *fileStore) daysStamp(now time.Time, days uint) string {
+	//nolint:gosec //Ok if days loses precision.
+	return fs.rotation.stamp(
+		RotateDaily.start(now.AddDate(0, 0, -1*int(days))),
+	)
+}
+
+// rangeStamps returns the stamps of the oldest and newest files included in
+// a history request between from and to.
+func (fs *fileStore) rangeStamps(from, to time.Time) (string, string) {
+	//nolint:gosmopolitan // Internal logs are all in local time.
+	return fs.rotation.stamp(from.In(time.Local)),
+		fs.rotation.stamp(to.In(time.Local))
+}
+
+// addAll passes each record for the wanted key to the add function.  A
+// non zero from or to restricts the records to those made between them
+// inclusive.  True is returned if a record after to was encountered.
+//
+//nolint:funlen // Ok.
 func (fs *fileStore) addAll(
-	fName, idWanted string, add func(Action, time.Time, string),
-) {
+	fName, idWanted string,
+	from, to time.Time,
+	add func(Action, time.Time, string),
+) bool {
 	var lastTS time.Time
 
 	defer func() {
 		fs.fName = ""
 		fs.fLineNum = 0
 		fs.fLine = ""
+		fs.fVersion = 0
 	}()
 
-	fPath := fs.dirName + string(os.PathSeparator) + fName
-	dataFile, err := os.Open(fPath) //nolint:gosec // Ok.
+	passedTo := false
+	fPath := fs.filePath(fName)
+	dataFile, err := openData(fPath)
 
 	if err == nil {
 		defer closeAndLogIfError(dataFile)
@@ -419,27 +829,39 @@ func (fs *fileStore) addAll(
 		fs.fLineNum = 0
 		scanner := bufio.NewScanner(dataFile)
 
-		for scanner.Scan() {
+		for !passedTo && scanner.Scan() {
 			fs.fLine = scanner.Text()
 			fs.fLineNum++
+
+			if fs.readHeader(fs.fLine) {
+				continue
+			}
+
+			if fs.fVersion == 0 {
+				break
+			}
 			timestamp, action, id, value, ok := fs.splitRecord(
 				fPath, scanner.Text(),
 			)
 
-			if ok && id == idWanted {
-				if lastTS.After(timestamp) {
-					fs.logMsg(
-						fmt.Sprintf(
-							"addAll: invalid timestamp out of sequence:"+
-								" received date: %s last date: %s",
-							timestamp.Format(fmtTimeStamp),
-							lastTS.Format(fmtTimeStamp),
-						),
-					)
-				} else {
-					add(action, timestamp, value)
-					lastTS = timestamp
-				}
+			switch {
+			case !ok:
+			case !to.IsZero() && timestamp.After(to):
+				passedTo = true
+			case id != idWanted:
+			case timestamp.Before(from):
+			case lastTS.After(timestamp):
+				fs.logMsg(
+					fmt.Sprintf(
+						"addAll: invalid timestamp out of sequence:"+
+							" received date: %s last date: %s",
+						timestamp.Format(fmtTimeStamp),
+						lastTS.Format(fmtTimeStamp),
+					),
+				)
+			default:
+				add(action, timestamp, value)
+				lastTS = timestamp
 			}
 		}
 
@@ -453,12 +875,16 @@ func (fs *fileStore) addAll(
 			),
 		)
 	}
+
+	return passedTo
 }
 
 // update adds or changes the value associated with a specific storage key.
 func (fs *fileStore) update(
 	key string, value string, floatValue float64,
 ) error {
+	defer fs.dispatch()
+
 	fs.rwMutex.Lock()
 	defer fs.rwMutex.Unlock()
 
@@ -467,7 +893,8 @@ func (fs *fileStore) update(
 		timestamp time.Time
 	)
 
-	if len(key) < minKeyLength || strings.Contains(key, groupSeparator) {
+	if len(key) < minKeyLength ||
+		strings.ContainsAny(key, groupSeparator+"\r\n") {
 		log.Printf(
 			"update(key=%q,value=%q) invalid key", key, value,
 		)
@@ -483,7 +910,10 @@ func (fs *fileStore) update(
 	}
 
 	fs.load(timestamp, key, value)
+	fs.recoverStale(key, timestamp)
 	fs.winDB[key].addValue(timestamp, floatValue)
+	fs.stageBuckets(fs.winDB[key])
+	fs.checkpointIfDue(timestamp)
 
 	return err
 }
@@ -499,18 +929,42 @@ func (fs *fileStore) Delete(datKey string) error {
 	}
 
 	delete(fs.data, datKey)
-	_, err := fs.writeToFile('D', datKey, "")
+	timestamp, err := fs.writeToFile('D', datKey, "")
+	fs.checkpointIfDue(timestamp)
 
 	return err
 }
 
 // Close the file when program exits.
 func (fs *fileStore) Close() error {
+	fs.compressing.Wait()
+	fs.stopWatch()
+	fs.flushBuckets()
+
+	if fs.dispatcher != nil {
+		defer fs.dispatcher.stop()
+	}
+
 	fs.rwMutex.Lock()
 	defer fs.rwMutex.Unlock()
 
+	var err error
+
+	if fs.opened && fs.checkpointInterval > 0 {
+		err = fs.writeCheckpoint()
+	}
+
+	if sErr := fs.syncFile(); err == nil {
+		err = sErr
+	}
+
+	fs.closeJournal()
+
 	fileToClose := fs.currentFile
-	fs.currentFileDate = ""
+	fs.currentFileStamp = ""
+	fs.currentFileSeq = 0
+	fs.currentFileSize = 0
+	fs.currentFileVer = 0
 	fs.currentFile = nil
 	fs.opened = false
 
@@ -518,50 +972,301 @@ func (fs *fileStore) Close() error {
 		closeAndLogIfError(fileToClose)
 	}
 
-	return nil
+	return err
 }
 
-// AddWindow creates a named window for the specified key.
+// SetReplayThresholds determines if window thresholds are checked (invoking
+// their callbacks) while history is replayed into the windows during Open.
+// The default is not to check.
+func (fs *fileStore) SetReplayThresholds(check bool) {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	fs.replayThresholds = check
+}
+
+// AddWindow creates a named window for the specified key.  A window added
+// to an opened store is back-filled from the entries already held for the
+// key and, if it is longer than those retained, from the data files.
 func (fs *fileStore) AddWindow(
 	datKey, winKey string, timePeriod time.Duration,
+) error {
+	return fs.addKeyWindow(datKey, winKey, func(wdb *winDB) error {
+		return wdb.addWindow(winKey, timePeriod)
+	})
+}
+
+// addKeyWindow creates a window for the key with add back-filling it if
+// the store is opened.
+func (fs *fileStore) addKeyWindow(
+	datKey, winKey string, add func(*winDB) error,
 ) error {
 	fs.rwMutex.Lock()
 	defer fs.rwMutex.Unlock()
 
-	if fs.opened {
-		return ErrOpenedWindow
-	}
-
 	winDB, ok := fs.winDB[datKey]
 	if !ok {
 		winDB = newWinDB(datKey)
 		fs.winDB[datKey] = winDB
 	}
 
-	return winDB.addWindow(winKey, timePeriod)
+	err := add(winDB)
+	if err == nil && fs.opened {
+		fs.backfillWindow(winDB, winDB.windows[winKey])
+		fs.startWatch()
+	}
+
+	return err
+}
+
+// RemoveWindow discards the named window and its thresholds.
+func (fs *fileStore) RemoveWindow(datKey, winKey string) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return ErrUnknownDatKey
+	}
+
+	return dw.removeWindow(winKey)
+}
+
+// backfillWindow loads the entries held for the key into a new (or reset)
+// window first extending them from the data files to cover what the window
+// requires.  Older values are only linked to the key's entries if the
+// window's state is derived from them.
+func (fs *fileStore) backfillWindow(wdb *winDB, w *window) {
+	if wdb.newestEntry == nil {
+		return
+	}
+
+	start, samples := w.backfill(wdb.newestEntry.timestamp)
+
+	if fs.toFloat != nil &&
+		(wdb.oldestEntry.timestamp.After(start) || wdb.numEntries < samples) {
+		older := fs.loadOlder(
+			wdb, start, samples-min(samples, wdb.numEntries),
+			w.mode == AverageTime,
+		)
+
+		if w.stateful() {
+			for i := range older {
+				w.load(&older[i])
+			}
+		} else {
+			for i := len(older) - 1; i >= 0; i-- {
+				wdb.newTail(older[i].timestamp, older[i].value)
+			}
+		}
+	}
+
+	for e := wdb.oldestEntry; e != nil; e = e.prev {
+		w.load(e)
+	}
+}
+
+// backfill returns the time from which (and the number of samples) the
+// window requires to be back-filled with from newest.
+func (w *window) backfill(newest time.Time) (time.Time, uint64) {
+	switch w.kind {
+	case windowCount:
+		return newest, w.samples
+	case windowEWMA:
+		return newest.Add(-w.period * ewmaBackfillHalfLives), 0
+	case windowTumbling:
+		return w.bucketStart(newest), 0
+	default:
+		return newest.Add(-w.period), 0
+	}
+}
+
+// loadOlder returns the key's values recorded before its oldest retained
+// entry from start along with at least the requested number of samples
+// oldest first.  If leading is set the last value before start (needed by
+// time weighted averages) is included as well.  The data files are read
+// newest first until all are covered.  Values before a delete are ignored.
+func (fs *fileStore) loadOlder(
+	wdb *winDB, start time.Time, samples uint64, leading bool,
+) []windowEntry {
+	var older []windowEntry
+
+	end := wdb.oldestEntry.timestamp
+	minStamp, maxStamp := fs.rangeStamps(start, end)
+
+	for i := len(fs.fileHistory) - 1; i >= 0; i-- {
+		filename := fs.fileHistory[i]
+		if !fs.fileInRange(filename, "", maxStamp) {
+			continue
+		}
+
+		if uint64(len(older)) >= samples &&
+			!fs.fileInRange(filename, minStamp, "") &&
+			(!leading || len(older) > 0 && older[0].timestamp.Before(start)) {
+			break
+		}
+
+		var entries []windowEntry
+
+		deleted := false
+		add := func(action Action, timestamp time.Time, raw string) {
+			if action == ActionDelete {
+				entries = entries[:0]
+				deleted = true
+
+				return
+			}
+
+			value, ok := fs.toFloat(raw)
+			if ok && timestamp.Before(end) {
+				entries = append(
+					entries, windowEntry{timestamp: timestamp, value: value},
+				)
+			}
+		}
+
+		fs.addAll(filename, wdb.datKey, time.Time{}, end, add)
+		older = append(entries, older...)
+
+		if deleted {
+			break
+		}
+	}
+
+	first := len(older) - min(len(older), int(samples)) //nolint:gosec // Ok.
+	for first > 0 && !older[first-1].timestamp.Before(start) {
+		first--
+	}
+
+	if leading && first > 0 {
+		first--
+	}
+
+	return older[first:]
 }
 
-// AddWindowThreshold provides a monitor of a average.
+// AddWindowThreshold provides a monitor of a average.  A threshold added
+// to a window already holding values is checked immediately.
 func (fs *fileStore) AddWindowThreshold(datKey, winKey string,
 	lowCritical, lowWarning, highWarning, highCritical float64,
 	notifyFunc ThresholdNotifyFunc,
 ) error {
+	defer fs.dispatch()
+
 	fs.rwMutex.Lock()
 	defer fs.rwMutex.Unlock()
 
-	if fs.opened {
-		return ErrOpenedWindowThreshold
-	}
-
 	dw, ok := fs.winDB[datKey]
 	if !ok {
 		return ErrUnknownDatKey
 	}
 
-	return dw.addThreshold(winKey,
+	if fs.dispatcher != nil && notifyFunc != nil {
+		notifyFunc = fs.dispatcher.wrap(notifyFunc)
+	}
+
+	err := dw.addThreshold(winKey,
 		lowCritical, lowWarning, highWarning, highCritical,
 		notifyFunc,
 	)
+	if err == nil {
+		fs.activateThreshold(dw.windows[winKey])
+	}
+
+	return err
+}
+
+// RemoveWindowThreshold discards a window's threshold identified by the
+// order it was added (starting at zero).  Later thresholds move down to
+// fill its place.
+func (fs *fileStore) RemoveWindowThreshold(
+	datKey, winKey string, index int,
+) error {
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return ErrUnknownDatKey
+	}
+
+	return dw.removeThreshold(winKey, index)
+}
+
+// UpdateWindowThreshold replaces the limits of a window's threshold
+// identified by the order it was added (starting at zero).  The threshold is
+// checked immediately against the window's current average notifying any
+// change.
+func (fs *fileStore) UpdateWindowThreshold(datKey, winKey string, index int,
+	lowCritical, lowWarning, highWarning, highCritical float64,
+) error {
+	return fs.updateThreshold(datKey, winKey, index,
+		func(t *threshold) error {
+			return t.setLimits(
+				lowCritical, lowWarning, highWarning, highCritical,
+			)
+		},
+	)
+}
+
+// SetWindowThresholdDeadband sets the margin a window's average must move
+// back past the boundary of its current level before a less severe level is
+// reported.  A more severe level is reported as soon as its boundary is
+// reached.  The threshold is identified by the order it was added (starting
+// at zero).  The default of zero reports every change.
+func (fs *fileStore) SetWindowThresholdDeadband(
+	datKey, winKey string, index int, deadband float64,
+) error {
+	if deadband < 0 {
+		return ErrInvalidDeadband
+	}
+
+	return fs.updateThreshold(datKey, winKey, index,
+		func(t *threshold) error {
+			t.deadband = deadband
+
+			return nil
+		},
+	)
+}
+
+// SetWindowThresholdDwell sets how long a window's average must remain in a
+// new level before it is reported.  The time is measured between the
+// timestamps of the samples received so a level is reported by the first
+// sample at least the dwell after the level began.  The threshold is
+// identified by the order it was added (starting at zero).  The default of
+// zero reports every change immediately.
+func (fs *fileStore) SetWindowThresholdDwell(
+	datKey, winKey string, index int, dwell time.Duration,
+) error {
+	if dwell < 0 {
+		return ErrInvalidDwell
+	}
+
+	return fs.updateThreshold(datKey, winKey, index,
+		func(t *threshold) error {
+			t.dwell = dwell
+
+			return nil
+		},
+	)
+}
+
+// updateThreshold applies a change to the indexed threshold of a window.
+func (fs *fileStore) updateThreshold(
+	datKey, winKey string, index int, change func(*threshold) error,
+) error {
+	defer fs.dispatch()
+
+	fs.rwMutex.Lock()
+	defer fs.rwMutex.Unlock()
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return ErrUnknownDatKey
+	}
+
+	return dw.updateThreshold(winKey, index, change)
 }
 
 // WindowAverage returns the specified window average.
@@ -589,3 +1294,57 @@ func (fs *fileStore) WindowCount(datKey, winKey string) (uint64, error) {
 
 	return dw.getCount(winKey)
 }
+
+// WindowMin returns the smallest value in the specified window.
+func (fs *fileStore) WindowMin(datKey, winKey string) (float64, error) {
+	return fs.windowStat(datKey, winKey, (*window).getMin)
+}
+
+// WindowMax returns the largest value in the specified window.
+func (fs *fileStore) WindowMax(datKey, winKey string) (float64, error) {
+	return fs.windowStat(datKey, winKey, (*window).getMax)
+}
+
+// WindowSum returns the total of the values in the specified window.
+func (fs *fileStore) WindowSum(datKey, winKey string) (float64, error) {
+	return fs.windowStat(datKey, winKey, (*window).getSum)
+}
+
+// WindowVariance returns the population variance of the values in the
+// specified window.
+func (fs *fileStore) WindowVariance(
+	datKey, winKey string,
+) (float64, error) {
+	return fs.windowStat(datKey, winKey, (*window).getVariance)
+}
+
+// WindowStdDev returns the population standard deviation of the values in
+// the specified window.
+func (fs *fileStore) WindowStdDev(datKey, winKey string) (float64, error) {
+	return fs.windowStat(datKey, winKey, (*window).getStdDev)
+}
+
+// WindowPercentile returns the q (0 <= q <= 1) percentile of the values in
+// the specified window (0.5 being the median).  The result is exact,
+// interpolating between the two closest values when q falls between them.
+func (fs *fileStore) WindowPercentile(
+	datKey, winKey string, q float64,
+) (float64, error) {
+	return fs.windowStat(datKey, winKey, func(w *window) (float64, error) {
+		return w.getPercentile(q)
+	})
+}
+
+func (fs *fileStore) windowStat(
+	datKey, winKey string, stat func(*window) (float64, error),
+) (float64, error) {
+	fs.rwMutex.RLock()
+	defer fs.rwMutex.RUnlock()
+
+	dw, ok := fs.winDB[datKey]
+	if !ok {
+		return 0, ErrUnknownDatKey
+	}
+
+	return dw.getStat(winKey, stat)
+}
@@ -22,6 +22,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -52,6 +53,16 @@ func setupWStoreBaseWithClock(
 	return dirName, filename, fStore
 }
 
+// setupFloatValues has the store parse its values as floats so they can be
+// windowed.
+func setupFloatValues(fStore *fileStore) {
+	fStore.toFloat = func(raw string) (float64, bool) {
+		value, err := strconv.ParseFloat(raw, 64)
+
+		return value, err == nil
+	}
+}
+
 func validateHistory(
 	chk *sztest.Chk,
 	fStore *fileStore,
@@ -303,7 +314,7 @@ func TestWStoreBase_LoadHistoryInvalidFile(t *testing.T) {
 
 	unknownFile := "UNKNOWN_FILE"
 	fPath := filepath.Join(dirName, unknownFile)
-	fStore.loadHistory(fPath)
+	fStore.loadHistory(fPath, 0)
 
 	chk.Log(
 		`loadHistory: open ` + fPath +
@@ -323,7 +334,7 @@ func TestWStoreBase_AddAllInvalidFile(t *testing.T) {
 
 	unknownFile := "UNKNOWN_FILE"
 	fPath := filepath.Join(dirName, unknownFile)
-	fStore.addAll(unknownFile, "", nil)
+	fStore.addAll(unknownFile, "", time.Time{}, time.Time{}, nil)
 
 	chk.Log(
 		`addAll(fName="` + unknownFile + `",isWanted=""): open ` + fPath +
@@ -594,7 +605,7 @@ func TestWStoreBase_UseCase1(t *testing.T) {
 	)
 
 	chk.NoErr(
-		fStore.AddWindowThreshold("key1", "win1", 1, 2, 3, 4, func(
+		fStore.AddWindowThreshold("key1", "win1", 1, 2, 3, 4, func( // clkNano8
 			d, k string, f, t ThresholdReason, v float64,
 		) {
 			log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
@@ -604,7 +615,7 @@ func TestWStoreBase_UseCase1(t *testing.T) {
 	)
 
 	chk.NoErr(
-		fStore.AddWindowThreshold("key2", "win2", 1, 3, 6, 9, func(
+		fStore.AddWindowThreshold("key2", "win2", 1, 3, 6, 9, func( // clkNano9
 			d, k string, f, t ThresholdReason, v float64,
 		) {
 			log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
@@ -616,30 +627,26 @@ func TestWStoreBase_UseCase1(t *testing.T) {
 	chk.NoErr(fStore.Open())
 	defer closeAndLogIfError(fStore)
 
-	chk.NoErr(fStore.update("key1", "Updated", 2))
-	chk.NoErr(fStore.update("key2", "Updated", 4))
+	chk.NoErr(fStore.update("key1", "Updated", 2)) // clkNano10
+	chk.NoErr(fStore.update("key2", "Updated", 4)) // clkNano11
 
 	validateHistory(chk, fStore, "key1", 2,
-		[]string{"{{clkNano4}}", "{{clkNano6}}", "{{clkNano8}}"},
+		[]string{"{{clkNano4}}", "{{clkNano6}}", "{{clkNano10}}"},
 		[]string{"PostDelete1", "PostDelete0", "Updated"},
 	)
 
 	validateHistory(chk, fStore, "key2", 2,
-		[]string{"{{clkNano5}}", "{{clkNano7}}", "{{clkNano9}}"},
+		[]string{"{{clkNano5}}", "{{clkNano7}}", "{{clkNano11}}"},
 		[]string{"PostDelete1", "PostDelete0", "Updated"},
 	)
 
-	chk.Err(
-		fStore.AddWindow("will", "fail", time.Second),
-		ErrOpenedWindow.Error(),
-	)
+	chk.NoErr(fStore.AddWindow("will", "succeed", time.Second))
 
-	chk.Err(
+	chk.NoErr(
 		fStore.AddWindowThreshold("key1", "win1", 1, 2, 3, 4,
 			func(_, _ string, _, _ ThresholdReason, _ float64) {
 			},
 		),
-		ErrOpenedWindowThreshold.Error(),
 	)
 
 	count, err := fStore.WindowCount("unknown", "unknown")
@@ -701,3 +708,395 @@ func TestWStoreBase_UseCase1(t *testing.T) {
 		`Threshold("key2","win2"),from: Unknown, to: Normal, value: 4`,
 	)
 }
+
+func setupWindowReplay(
+	chk *sztest.Chk, replayThresholds bool,
+) *fileStore {
+	chk.T().Helper()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	setupFloatValues(fStore)
+	fStore.SetReplayThresholds(replayThresholds)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{ /* clkNano0  */ "", "|U|key1|10"},
+			{ /* clkNano1  */ "", "|U|key1|20"},
+			{ /* clkNano2  */ "", "|U|key1|30"},
+			{ /* clkNano3  */ "", "|U|key2|notAFloat"},
+		}),
+	)
+
+	chk.NoErr(fStore.AddWindow("key1", "win1", time.Second))
+	chk.NoErr(fStore.AddWindow("key1", "win10", time.Second*10))
+	chk.NoErr(fStore.AddWindow("key2", "win1", time.Second))
+
+	chk.NoErr(
+		fStore.AddWindowThreshold("key1", "win10", 0, 5, 15, 50, func(
+			d, k string, f, t ThresholdReason, v float64,
+		) {
+			log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
+				d, k, f, t, v,
+			)
+		}),
+	)
+
+	chk.NoErr(fStore.Open())
+
+	return fStore
+}
+
+func validateWindow(
+	chk *sztest.Chk,
+	fStore *fileStore,
+	datKey, winKey string,
+	expCount uint64, expAvg float64,
+) {
+	chk.T().Helper()
+
+	count, err := fStore.WindowCount(datKey, winKey)
+	chk.NoErr(err)
+	chk.Uint64(count, expCount)
+
+	average, err := fStore.WindowAverage(datKey, winKey)
+	chk.NoErr(err)
+	chk.Float64(average, expAvg, 0)
+}
+
+func TestWStoreBase_OpenReplaysWindows(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	fStore := setupWindowReplay(chk, false)
+	defer closeAndLogIfError(fStore)
+
+	validateWindow(chk, fStore, "key1", "win1", 2, 25)
+	validateWindow(chk, fStore, "key1", "win10", 3, 20)
+
+	count, err := fStore.WindowCount("key2", "win1")
+	chk.Uint64(count, 0)
+	chk.Err(err, ErrNoWinData.Error())
+
+	chk.NoErr(fStore.update("key1", "40", 40))
+
+	validateWindow(chk, fStore, "key1", "win1", 1, 40)
+	validateWindow(chk, fStore, "key1", "win10", 4, 25)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{hPath0}}`,
+		`Threshold("key1","win10"),from: Unknown, to: High Warning, value: 25`,
+	)
+}
+
+func TestWStoreBase_OpenReplaysWindowThresholds(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	fStore := setupWindowReplay(chk, true)
+	defer closeAndLogIfError(fStore)
+
+	validateWindow(chk, fStore, "key1", "win1", 2, 25)
+	validateWindow(chk, fStore, "key1", "win10", 3, 20)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`Threshold("key1","win10"),from: Unknown, to: Normal, value: 10`,
+		`Threshold("key1","win10"),from: Normal, to: High Warning, value: 15`,
+		`starting path retrieved as: {{hPath0}}`,
+	)
+}
+
+func validateHistoryRange(
+	chk *sztest.Chk,
+	fStore *fileStore,
+	datKey string,
+	from, to time.Time,
+	expTSlice, expVSlice []string,
+) {
+	chk.T().Helper()
+
+	var (
+		tSlice []string
+		vSlice []string
+	)
+
+	fStore.getHistoryRange(datKey, from, to,
+		func(a Action, ts time.Time, raw string) {
+			if a == ActionDelete {
+				tSlice = nil
+				vSlice = nil
+			} else {
+				tSlice = append(tSlice, ts.Format(fmtTimeStamp))
+				vSlice = append(vSlice, raw)
+			}
+		},
+	)
+	chk.StrSlice(tSlice, expTSlice)
+	chk.StrSlice(vSlice, expVSlice)
+}
+
+func TestWStoreBase_GetHistoryRange(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 2, dirName, filename, [][2]string{
+			{"", "|U|key3|Clock"},
+			{"20000513140000.000000000", "|U|key1|TwoDays"},
+		}),
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 1, dirName, filename, [][2]string{
+			{"", "|U|key3|Clock"},
+			{"20000514130000.000000000", "|U|key1|A"},
+			{"20000514140000.000000000", "|U|key1|B"},
+			{"20000514150000.000000000", "|U|key1|C"},
+			{"20000514153000.000000000", "|U|key2|X"},
+			{"20000514160000.000000000", "|U|key1|D"},
+			{"20000514163000.000000000", "|U|k|Bad"},
+		}),
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{"", "|U|key3|Clock"},
+			{"20000515130000.000000000", "|U|key1|E"},
+			{"20000515140000.000000000", "|D|key1|"},
+			{"20000515150000.000000000", "|U|key1|F"},
+		}),
+	)
+
+	chk.NoErr(fStore.Open())
+	defer closeAndLogIfError(fStore)
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2000, 5, day, hour, minute, 0, 0, time.Local)
+	}
+
+	validateHistoryRange(chk, fStore, "key1", at(14, 14, 0), at(14, 15, 30),
+		[]string{"20000514140000.000000000", "20000514150000.000000000"},
+		[]string{"B", "C"},
+	)
+
+	validateHistoryRange(chk, fStore, "key1", at(14, 14, 30), at(15, 13, 30),
+		[]string{
+			"20000514150000.000000000",
+			"20000514160000.000000000",
+			"20000515130000.000000000",
+		},
+		[]string{"C", "D", "E"},
+	)
+
+	validateHistoryRange(chk, fStore, "key1", at(14, 14, 30), at(15, 16, 0),
+		[]string{"20000515150000.000000000"},
+		[]string{"F"},
+	)
+
+	validateHistoryRange(chk, fStore, "key1", at(15, 16, 0), at(14, 14, 0),
+		nil,
+		nil,
+	)
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`splitRecord: invalid key length (>= 2 characters): "k": {{hPath1}}:7`+
+			` - "20000514163000.000000000|U|k|Bad"`,
+		`starting path retrieved as: {{hPath0}}`,
+		`splitRecord: invalid key length (>= 2 characters): "k": {{hPath1}}:7`+
+			` - "20000514163000.000000000|U|k|Bad"`,
+		`splitRecord: invalid key length (>= 2 characters): "k": {{hPath1}}:7`+
+			` - "20000514163000.000000000|U|k|Bad"`,
+	)
+}
+
+func TestWStoreBase_WindowAggregates(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(fStore.AddWindow("key1", "w1", time.Minute))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		chk.NoErr(
+			fStore.update("key1", strconv.FormatFloat(v, 'g', -1, 64), v),
+		)
+	}
+
+	got, err := fStore.WindowMin("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(got, 2, 0)
+
+	got, err = fStore.WindowMax("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(got, 9, 0)
+
+	got, err = fStore.WindowSum("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(got, 40, 0)
+
+	got, err = fStore.WindowVariance("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(got, 4, 1e-12)
+
+	got, err = fStore.WindowStdDev("key1", "w1")
+	chk.NoErr(err)
+	chk.Float64(got, 2, 1e-12)
+
+	got, err = fStore.WindowPercentile("key1", "w1", 0.5)
+	chk.NoErr(err)
+	chk.Float64(got, 4.5, 1e-12)
+
+	_, err = fStore.WindowPercentile("key1", "w1", 2)
+	chk.Err(err, ErrInvalidPercentile.Error())
+
+	_, err = fStore.WindowMin("unknown", "w1")
+	chk.Err(err, ErrUnknownDatKey.Error())
+
+	_, err = fStore.WindowMax("key1", "unknown")
+	chk.Err(err, ErrUnknownWinKey.Error())
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func TestWStoreBase_ConfigureOpened(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, fStore := setupWStoreBaseWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	setupFloatValues(fStore)
+
+	chk.NoErr(fStore.AddWindow("key1", "short", time.Second*2))
+	chk.NoErr(fStore.Open())
+
+	defer closeAndLogIfError(fStore)
+
+	for _, v := range []float64{1, 2} {
+		chk.NoErr(
+			fStore.update("key1", strconv.FormatFloat(v, 'g', -1, 64), v),
+		)
+	}
+
+	chk.NoErr(fStore.Delete("key1"))
+
+	for _, v := range []float64{3, 4, 5, 6} {
+		chk.NoErr(
+			fStore.update("key1", strconv.FormatFloat(v, 'g', -1, 64), v),
+		)
+	}
+
+	chk.Int(fStore.winDB["key1"].count(), 3)
+
+	// Values older than those retained are read from the data file ignoring
+	// those before the delete.
+	chk.NoErr(fStore.AddWindow("key1", "long", time.Second*10))
+	chk.Err(
+		fStore.AddWindow("key1", "long", time.Second),
+		ErrDupWinKey.Error(),
+	)
+
+	count, err := fStore.WindowCount("key1", "long")
+	chk.NoErr(err)
+	chk.Uint64(count, 4)
+
+	avg, err := fStore.WindowAverage("key1", "long")
+	chk.NoErr(err)
+	chk.Float64(avg, 4.5, 0)
+
+	notify := func(d, k string, f, t ThresholdReason, v float64) {
+		log.Printf("Threshold(%q,%q),from: %v, to: %v, value: %g",
+			d, k, f, t, v,
+		)
+	}
+
+	chk.NoErr(fStore.AddWindowThreshold("key1", "long", 1, 2, 5, 6, notify))
+	chk.NoErr(fStore.UpdateWindowThreshold("key1", "long", 0, 1, 2, 3, 4))
+	chk.NoErr(fStore.UpdateWindowThreshold("key1", "long", 0, 1, 2, 4, 5))
+	chk.Err(
+		fStore.UpdateWindowThreshold("key1", "long", 0, 4, 3, 2, 1),
+		ErrInvalidThresholdOrder.Error(),
+	)
+	chk.Err(
+		fStore.UpdateWindowThreshold("key1", "long", 1, 1, 2, 3, 4),
+		ErrUnknownThreshold.Error(),
+	)
+	chk.Err(
+		fStore.UpdateWindowThreshold("key1", "none", 0, 1, 2, 3, 4),
+		ErrUnknownWinKey.Error(),
+	)
+	chk.Err(
+		fStore.UpdateWindowThreshold("none", "long", 0, 1, 2, 3, 4),
+		ErrUnknownDatKey.Error(),
+	)
+
+	chk.NoErr(fStore.SetWindowThresholdDeadband("key1", "long", 0, 0.5))
+	chk.Err(
+		fStore.SetWindowThresholdDeadband("key1", "long", 0, -1),
+		ErrInvalidDeadband.Error(),
+	)
+	chk.Err(
+		fStore.SetWindowThresholdDeadband("key1", "long", 1, 1),
+		ErrUnknownThreshold.Error(),
+	)
+
+	thresholds, err := fStore.Thresholds("key1", "long")
+	chk.NoErr(err)
+	chk.Float64(thresholds[0].Deadband, 0.5, 0)
+
+	chk.NoErr(fStore.RemoveWindowThreshold("key1", "long", 0))
+	chk.Err(
+		fStore.RemoveWindowThreshold("key1", "long", 0),
+		ErrUnknownThreshold.Error(),
+	)
+	chk.Err(
+		fStore.RemoveWindowThreshold("none", "long", 0),
+		ErrUnknownDatKey.Error(),
+	)
+
+	chk.NoErr(fStore.update("key1", "9", 9)) // No notification.
+
+	chk.NoErr(fStore.RemoveWindow("key1", "long"))
+	chk.Err(fStore.RemoveWindow("key1", "long"), ErrUnknownWinKey.Error())
+	chk.Err(fStore.RemoveWindow("none", "long"), ErrUnknownDatKey.Error())
+
+	_, err = fStore.WindowAverage("key1", "long")
+	chk.Err(err, ErrUnknownWinKey.Error())
+	chk.Int(int(fStore.winDB["key1"].maxPeriod), int(time.Second*2))
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+		`Threshold("key1","long"),from: Unknown, to: Normal, value: 4.5`,
+		`Threshold("key1","long"),from: Normal, to: High Critical, value: 4.5`,
+		`Threshold("key1","long"),from: High Critical, to: High Warning, `+
+			`value: 4.5`,
+	)
+}
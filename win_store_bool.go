@@ -19,91 +19,50 @@
 package szstore
 
 import (
+	"fmt"
 	"strconv"
-	"time"
 )
 
-// WStoreBool contains and links the underlying Storage implementation
-// and its associated numeric window.
-type WStoreBool struct {
-	*fileStore
-	//	rwMutex sync.RWMutex
-}
+type boolCodec struct{}
 
-// NewBool a new Store object.
-func NewBool(dirName, filenameRoot string) *WStoreBool {
-	store := newFileStore(dirName, filenameRoot)
+func (boolCodec) Name() string {
+	return "Bool"
+}
 
-	return &WStoreBool{
-		fileStore: store,
-	}
+func (boolCodec) Encode(value bool) string {
+	return strconv.FormatBool(value)
 }
 
-func (s *WStoreBool) parseBool(raw string) (bool, bool) {
+func (boolCodec) Decode(raw string) (bool, error) {
 	switch raw {
 	case "false":
-		return false, true
+		return false, nil
 	case "true":
-		return true, true
+		return true, nil
 	default:
-		s.logMsg(
-			"parseBool: invalid syntax: " + strconv.Quote(raw),
-		)
-
-		return false, false
+		return false, fmt.Errorf("%w: %q", ErrInvalidSyntax, raw)
 	}
 }
 
-// Update creates or updates a new key value.
-func (s *WStoreBool) Update(key string, value bool) error {
-	var v float64
+func (boolCodec) Float(value bool) float64 {
 	if value {
-		v = 1.0
+		return 1.0
 	}
 
-	return s.fileStore.update(key, strconv.FormatBool(value), v)
+	return 0.0
 }
 
-// Get returns the most recent value for the associated key.
-func (s *WStoreBool) Get(datKey string) (time.Time, bool, bool) {
-	ts, v, ok := s.fileStore.get(datKey)
-	if ok {
-		value, ok := s.parseBool(v)
-		if ok {
-			return ts, value, true
-		}
-	}
-
-	return time.Time{}, false, false
+// WStoreBool contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreBool struct {
+	*WStore[bool]
 }
 
-// GetHistoryDays returns all values made over the specified number of days.
-// A zero represent only the current day.
-func (s *WStoreBool) GetHistoryDays(
-	datKey string, days uint,
-) ([]time.Time, []bool) {
-	var (
-		timestamps []time.Time
-		values     []bool
-	)
-
-	s.fileStore.getHistoryDays(
-		datKey, days, func(a Action, timestamp time.Time, raw string,
-		) {
-			if a == ActionDelete {
-				timestamps = nil
-				values = nil
-			} else {
-				vParsed, ok := s.parseBool(raw)
-				if ok {
-					timestamps = append(timestamps, timestamp)
-					values = append(values, vParsed)
-				}
-			}
-		},
-	)
-
-	return timestamps, values
+// NewBool a new Store object.
+func NewBool(dirName, filenameRoot string) *WStoreBool {
+	return &WStoreBool{
+		WStore: New(dirName, filenameRoot, boolCodec{}),
+	}
 }
 
 // AddWindowThreshold adds the provided threshold data to the indicated numeric
@@ -112,11 +71,9 @@ func (s *WStoreBool) AddWindowThreshold(datKey, winKey string,
 	lowCritical, lowWarning, highWarning, highCritical float64,
 	notifyFunc ThresholdNotifyFunc,
 ) error {
-	if lowCritical < 0.0 || lowCritical > 1.0 ||
-		lowWarning < 0.0 || lowWarning > 1.0 ||
-		highWarning < 0.0 || highWarning > 1.0 ||
-		highCritical < 0.0 || highCritical > 1.0 ||
-		false {
+	if !validBoolThresholds(
+		lowCritical, lowWarning, highWarning, highCritical,
+	) {
 		return ErrInvalidBoolThreshold
 	}
 
@@ -125,3 +82,64 @@ func (s *WStoreBool) AddWindowThreshold(datKey, winKey string,
 		notifyFunc,
 	)
 }
+
+// AddValueThreshold adds the provided threshold data to the indicated key's
+// raw values.
+func (s *WStoreBool) AddValueThreshold(datKey string,
+	lowCritical, lowWarning, highWarning, highCritical float64,
+	notifyFunc ThresholdNotifyFunc,
+) error {
+	if !validBoolThresholds(
+		lowCritical, lowWarning, highWarning, highCritical,
+	) {
+		return ErrInvalidBoolThreshold
+	}
+
+	return s.fileStore.AddValueThreshold(datKey,
+		lowCritical, lowWarning, highWarning, highCritical,
+		notifyFunc,
+	)
+}
+
+// UpdateWindowThreshold replaces the limits of the indexed threshold of the
+// indicated numeric window.
+func (s *WStoreBool) UpdateWindowThreshold(datKey, winKey string, index int,
+	lowCritical, lowWarning, highWarning, highCritical float64,
+) error {
+	if !validBoolThresholds(
+		lowCritical, lowWarning, highWarning, highCritical,
+	) {
+		return ErrInvalidBoolThreshold
+	}
+
+	return s.fileStore.UpdateWindowThreshold(datKey, winKey, index,
+		lowCritical, lowWarning, highWarning, highCritical,
+	)
+}
+
+// UpdateValueThreshold replaces the limits of the indexed threshold of the
+// indicated key's values.
+func (s *WStoreBool) UpdateValueThreshold(datKey string, index int,
+	lowCritical, lowWarning, highWarning, highCritical float64,
+) error {
+	if !validBoolThresholds(
+		lowCritical, lowWarning, highWarning, highCritical,
+	) {
+		return ErrInvalidBoolThreshold
+	}
+
+	return s.fileStore.UpdateValueThreshold(datKey, index,
+		lowCritical, lowWarning, highWarning, highCritical,
+	)
+}
+
+// validBoolThresholds checks all limits lie between zero and one.
+func validBoolThresholds(limits ...float64) bool {
+	for _, limit := range limits {
+		if limit < 0.0 || limit > 1.0 {
+			return false
+		}
+	}
+
+	return true
+}
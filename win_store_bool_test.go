@@ -173,6 +173,13 @@ func TestSzStoreBool_InvalidBoolThresholds(t *testing.T) {
 		ErrInvalidBoolThreshold.Error(),
 	)
 
+	chk.Err(
+		boolStore.UpdateWindowThreshold(
+			"key2", "18Milliseconds", 0, 0.2, 0.4, 0.6, 1.8,
+		),
+		ErrInvalidBoolThreshold.Error(),
+	)
+
 	chk.Err(
 		boolStore.AddWindowThreshold(
 			"key2", "18Milliseconds", 0.4, 0.2, 0.6, 0.8,
@@ -0,0 +1,153 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+// WStoreInt contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreInt = WStore[int]
+
+// NewInt a new Store object.
+func NewInt(dirName, filenameRoot string) *WStoreInt {
+	return New(
+		dirName, filenameRoot, intCodec[int]{name: "Int", bitSize: 0},
+	)
+}
+
+// WStoreInt8 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreInt8 = WStore[int8]
+
+// NewInt8 a new Store object.
+func NewInt8(dirName, filenameRoot string) *WStoreInt8 {
+	return New(
+		dirName, filenameRoot, intCodec[int8]{name: "Int8", bitSize: 8},
+	)
+}
+
+// WStoreInt16 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreInt16 = WStore[int16]
+
+// NewInt16 a new Store object.
+func NewInt16(dirName, filenameRoot string) *WStoreInt16 {
+	return New(
+		dirName, filenameRoot, intCodec[int16]{name: "Int16", bitSize: 16},
+	)
+}
+
+// WStoreInt32 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreInt32 = WStore[int32]
+
+// NewInt32 a new Store object.
+func NewInt32(dirName, filenameRoot string) *WStoreInt32 {
+	return New(
+		dirName, filenameRoot, intCodec[int32]{name: "Int32", bitSize: 32},
+	)
+}
+
+// WStoreInt64 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreInt64 = WStore[int64]
+
+// NewInt64 a new Store object.
+func NewInt64(dirName, filenameRoot string) *WStoreInt64 {
+	return New(
+		dirName, filenameRoot, intCodec[int64]{name: "Int64", bitSize: 64},
+	)
+}
+
+// WStoreUint contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreUint = WStore[uint]
+
+// NewUint a new Store object.
+func NewUint(dirName, filenameRoot string) *WStoreUint {
+	return New(
+		dirName, filenameRoot, uintCodec[uint]{name: "Uint", bitSize: 0},
+	)
+}
+
+// WStoreUint8 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreUint8 = WStore[uint8]
+
+// NewUint8 a new Store object.
+func NewUint8(dirName, filenameRoot string) *WStoreUint8 {
+	return New(
+		dirName, filenameRoot, uintCodec[uint8]{name: "Uint8", bitSize: 8},
+	)
+}
+
+// WStoreUint16 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreUint16 = WStore[uint16]
+
+// NewUint16 a new Store object.
+func NewUint16(dirName, filenameRoot string) *WStoreUint16 {
+	return New(
+		dirName, filenameRoot, uintCodec[uint16]{name: "Uint16", bitSize: 16},
+	)
+}
+
+// WStoreUint32 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreUint32 = WStore[uint32]
+
+// NewUint32 a new Store object.
+func NewUint32(dirName, filenameRoot string) *WStoreUint32 {
+	return New(
+		dirName, filenameRoot, uintCodec[uint32]{name: "Uint32", bitSize: 32},
+	)
+}
+
+// WStoreUint64 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreUint64 = WStore[uint64]
+
+// NewUint64 a new Store object.
+func NewUint64(dirName, filenameRoot string) *WStoreUint64 {
+	return New(
+		dirName, filenameRoot, uintCodec[uint64]{name: "Uint64", bitSize: 64},
+	)
+}
+
+// WStoreFloat32 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreFloat32 = WStore[float32]
+
+// NewFloat32 a new Store object.
+func NewFloat32(dirName, filenameRoot string) *WStoreFloat32 {
+	return New(
+		dirName, filenameRoot,
+		floatCodec[float32]{name: "Float32", bitSize: 32},
+	)
+}
+
+// WStoreFloat64 contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreFloat64 = WStore[float64]
+
+// NewFloat64 a new Store object.
+func NewFloat64(dirName, filenameRoot string) *WStoreFloat64 {
+	return New(
+		dirName, filenameRoot,
+		floatCodec[float64]{name: "Float64", bitSize: 64},
+	)
+}
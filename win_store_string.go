@@ -19,114 +19,83 @@
 package szstore
 
 import (
-	"strconv"
+	"fmt"
 	"strings"
-	"time"
 )
 
-// WStoreString contains and links the underlying Storage implementation
-// and its associated numeric window.
-type WStoreString struct {
-	*fileStore
+type stringCodec struct {
 	invalidChars   []rune
 	numValidValues int
 	validValues    []string
 }
 
-// NewString a new Store object.
-func NewString(dirName, filenameRoot string) *WStoreString {
-	s := newFileStore(dirName, filenameRoot)
-	newWStoreString := new(WStoreString)
-	newWStoreString.fileStore = s
-
-	return newWStoreString
-}
-
-// SetInvalidChars set the character set of invalid characters.
-func (s *WStoreString) SetInvalidChars(c []rune) {
-	s.invalidChars = c
+func (c *stringCodec) Name() string {
+	return "String"
 }
 
-// SetValidValues sets a list of valid values.
-func (s *WStoreString) SetValidValues(v []string) {
-	s.validValues = v
-	s.numValidValues = len(v)
+func (c *stringCodec) Encode(value string) string {
+	return value
 }
 
-func (s *WStoreString) parseString(raw string) (string, bool) {
-	for _, c := range s.invalidChars {
-		if strings.ContainsRune(raw, c) {
-			s.logMsg("parseString: invalid character: " +
-				strconv.Quote(string(c)))
-
-			return "", false
+func (c *stringCodec) Decode(raw string) (string, error) {
+	for _, r := range c.invalidChars {
+		if strings.ContainsRune(raw, r) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidCharacter, string(r))
 		}
 	}
 
-	if s.numValidValues > 0 {
+	if c.numValidValues > 0 {
 		found := false
-		for i, mi := 0, s.numValidValues; i < mi && !found; i++ {
-			found = s.validValues[i] == raw
+		for i, mi := 0, c.numValidValues; i < mi && !found; i++ {
+			found = c.validValues[i] == raw
 		}
 
 		if !found {
-			s.logMsg("parseString: invalid value: " +
-				strconv.Quote(raw))
-
-			return "", false
+			return "", fmt.Errorf("%w: %q", ErrInvalidValue, raw)
 		}
 	}
 
-	return raw, true
+	return raw, nil
 }
 
-// Update creates or updates a new key value.
-func (s *WStoreString) Update(key, value string) error {
-	v, ok := s.parseString(value)
-	if !ok {
-		return ErrInvalidStoreString
-	}
+func (c *stringCodec) Float(value string) float64 {
+	return float64(len(value))
+}
 
-	return s.fileStore.update(key, v, float64(len(v)))
+// WStoreString contains and links the underlying Storage implementation
+// and its associated numeric window.
+type WStoreString struct {
+	*WStore[string]
+	strCodec *stringCodec
 }
 
-// Get returns the most recent value for the associated key.
-func (s *WStoreString) Get(key string) (time.Time, string, bool) {
-	ts, v, ok := s.fileStore.get(key)
-	if ok {
-		value, ok := s.parseString(v)
-		if ok {
-			return ts, value, true
-		}
+// NewString a new Store object.
+func NewString(dirName, filenameRoot string) *WStoreString {
+	codec := new(stringCodec)
+
+	return &WStoreString{
+		WStore:   New[string](dirName, filenameRoot, codec),
+		strCodec: codec,
 	}
+}
+
+// SetInvalidChars set the character set of invalid characters.
+func (s *WStoreString) SetInvalidChars(c []rune) {
+	s.strCodec.invalidChars = c
+}
 
-	return time.Time{}, "", false
+// SetValidValues sets a list of valid values.
+func (s *WStoreString) SetValidValues(v []string) {
+	s.strCodec.validValues = v
+	s.strCodec.numValidValues = len(v)
 }
 
-// GetHistoryDays returns all values made over the specified number of days.
-// A zero represent only the current day.
-func (s *WStoreString) GetHistoryDays(
-	key string, days uint,
-) ([]time.Time, []string) {
-	var (
-		timestamps []time.Time
-		values     []string
-	)
-
-	s.fileStore.getHistoryDays(key, days,
-		func(a Action, timestamp time.Time, raw string) {
-			if a == ActionDelete {
-				timestamps = nil
-				values = nil
-			} else {
-				vParsed, ok := s.parseString(raw)
-				if ok {
-					timestamps = append(timestamps, timestamp)
-					values = append(values, vParsed)
-				}
-			}
-		},
-	)
-
-	return timestamps, values
+// Update creates or updates a new key value.
+func (s *WStoreString) Update(key, value string) error {
+	v, ok := s.parse(value)
+	if !ok {
+		return ErrInvalidStoreString
+	}
+
+	return s.WStore.Update(key, v)
 }
@@ -0,0 +1,250 @@
+/*
+   Szerszam Windowed Storage Library: szstore.
+   Copyright (C) 2023, 2024  Leslie Dancsecs
+
+   This program is free software: you can redistribute it and/or modify
+   it under the terms of the GNU General Public License as published by
+   the Free Software Foundation, either version 3 of the License, or
+   (at your option) any later version.
+
+   This program is distributed in the hope that it will be useful,
+   but WITHOUT ANY WARRANTY; without even the implied warranty of
+   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+   GNU General Public License for more details.
+
+   You should have received a copy of the GNU General Public License
+   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package szstore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dancsecs/sztest"
+)
+
+// durationCodec demonstrates a user supplied codec.
+type durationCodec struct{}
+
+func (durationCodec) Name() string {
+	return "Duration"
+}
+
+func (durationCodec) Encode(value time.Duration) string {
+	return value.String()
+}
+
+func (durationCodec) Decode(raw string) (time.Duration, error) {
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSyntax, raw)
+	}
+
+	return value, nil
+}
+
+func (durationCodec) Float(value time.Duration) float64 {
+	return value.Seconds()
+}
+
+func setupWStoreDurationWithClock(
+	chk *sztest.Chk,
+	initialTime time.Time, inc ...time.Duration,
+) (string, string, *WStore[time.Duration]) {
+	chk.T().Helper()
+
+	chk.ClockSet(initialTime, inc...)
+	chk.ClockAddSub(sztest.ClockSubNano)
+
+	dirName := chk.CreateTmpDir()
+
+	const filename = "dataFile"
+
+	durationStore := New(dirName, filename, durationCodec{})
+	durationStore.ts = chk.ClockNext
+
+	chk.AddSub("{{dir}}", dirName)
+	chk.AddSub("{{file}}", filename)
+
+	return dirName, filename, durationStore
+}
+
+func Test_WStore_CustomCodec(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, durationStore := setupWStoreDurationWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Millisecond*20,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{ /* clkNano0 */ "", "|U|key1|abc"},
+			{ /* clkNano1 */ "", "|U|key1|1m30s"},
+		}),
+	)
+
+	chk.NoErr(durationStore.AddWindow("key1", "win1", time.Second))
+
+	chk.NoErr(durationStore.Open())
+	defer closeAndLogIfError(durationStore)
+
+	chk.NoErr(durationStore.Update("key1", time.Second*30)) // clkNano2
+
+	timestamp, value, ok := durationStore.Get("key1")
+	chk.True(ok)
+	chk.Str(timestamp.Format(fmtTimeStamp), "{{clkNano2}}")
+	chk.Int64(int64(value), int64(time.Second*30))
+
+	average, err := durationStore.WindowAverage("key1", "win1")
+	chk.NoErr(err)
+	chk.Float64(average, 60, 0)
+
+	tsSlice, vSlice := durationStore.GetHistoryDays("key1", 0)
+
+	tSlice := make([]string, len(tsSlice))
+	for i, ts := range tsSlice {
+		tSlice[i] = ts.Format(fmtTimeStamp)
+	}
+
+	chk.StrSlice(tSlice, []string{"{{clkNano1}}", "{{clkNano2}}"})
+	chk.Int(len(vSlice), 2)
+	chk.Int64(int64(vSlice[0]), int64(time.Second*90))
+	chk.Int64(int64(vSlice[1]), int64(time.Second*30))
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{hPath0}}`,
+		`parseDuration: invalid syntax: "abc"`+
+			`: {{hPath0}}:1 - "{{clkNano0}}|U|key1|abc"`,
+	)
+}
+
+func Test_WStore_GetHistoryRange(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	_, _, durationStore := setupWStoreDurationWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Minute,
+	)
+
+	chk.NoErr(durationStore.Open()) // clkNano0
+
+	chk.NoErr(durationStore.Update("key1", time.Second*1)) // clkNano1
+	chk.NoErr(durationStore.Update("key1", time.Second*2)) // clkNano2
+
+	from, _, _ := durationStore.Get("key1")
+
+	chk.NoErr(durationStore.Update("key1", time.Second*3)) // clkNano3
+
+	to, _, _ := durationStore.Get("key1")
+
+	chk.NoErr(durationStore.Update("key1", time.Second*4)) // clkNano4
+
+	tsSlice, vSlice := durationStore.GetHistoryRange("key1", from, to)
+
+	tSlice := make([]string, len(tsSlice))
+	for i, ts := range tsSlice {
+		tSlice[i] = ts.Format(fmtTimeStamp)
+	}
+
+	chk.StrSlice(tSlice, []string{"{{clkNano2}}", "{{clkNano3}}"})
+	chk.Int(len(vSlice), 2)
+	chk.Int64(int64(vSlice[0]), int64(time.Second*2))
+	chk.Int64(int64(vSlice[1]), int64(time.Second*3))
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path generated as: {{dir}}/{{file}}_20000515.dat`,
+	)
+}
+
+func Test_WStore_HistoryIterator(t *testing.T) {
+	chk := sztest.CaptureLog(t)
+	defer chk.Release()
+
+	dirName, filename, durationStore := setupWStoreDurationWithClock(
+		chk,
+		time.Date(2000, 5, 15, 12, 24, 56, 0, time.Local),
+		time.Second,
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 1, dirName, filename, [][2]string{
+			{ /* clkNano0 */ "", "|U|key1|1s"},
+			{ /* clkNano1 */ "", "|U|key2|1m"},
+			{ /* clkNano2 */ "", "|U|key1|abc"},
+			{ /* clkNano3 */ "", "|U|key1|2s"},
+		}),
+	)
+
+	chk.NoErr(
+		buildHistoryFile(chk, 0, dirName, filename, [][2]string{
+			{ /* clkNano4 */ "", "|D|key1|"},
+			{ /* clkNano5 */ "", "|U|key1|3s"},
+		}),
+	)
+
+	chk.NoErr(durationStore.Open())
+	defer closeAndLogIfError(durationStore)
+
+	var (
+		tSlice []string
+		vSlice []time.Duration
+	)
+
+	for ts, v := range durationStore.HistoryDays("key1", 1) { // clkNano6
+		tSlice = append(tSlice, ts.Format(fmtTimeStamp))
+		vSlice = append(vSlice, v)
+	}
+
+	chk.StrSlice(
+		tSlice, []string{"{{clkNano0}}", "{{clkNano3}}", "{{clkNano5}}"},
+	)
+	chk.Int(len(vSlice), 3)
+	chk.Int64(int64(vSlice[0]), int64(time.Second*1))
+	chk.Int64(int64(vSlice[1]), int64(time.Second*2))
+	chk.Int64(int64(vSlice[2]), int64(time.Second*3))
+
+	// Break early updating the store from within the loop.
+	tSlice = nil
+
+	for ts := range durationStore.HistoryDays("key1", 1) { // clkNano7
+		tSlice = append(tSlice, ts.Format(fmtTimeStamp))
+
+		chk.NoErr(durationStore.Update("key1", time.Second*4)) // clkNano8
+
+		break
+	}
+
+	chk.StrSlice(tSlice, []string{"{{clkNano0}}"})
+
+	from, _, _ := durationStore.Get("key1")
+	tSlice = nil
+
+	for ts := range durationStore.HistoryRange("key1", from, from) {
+		tSlice = append(tSlice, ts.Format(fmtTimeStamp))
+	}
+
+	chk.StrSlice(tSlice, []string{"{{clkNano8}}"})
+
+	for range durationStore.HistoryRange("key1", from, from.Add(-1)) {
+		t.Fatal("unexpected value from an inverted range")
+	}
+
+	chk.Log(
+		`opening file based szStore {{file}} in directory {{dir}}`,
+		`starting path retrieved as: {{hPath0}}`,
+		`parseDuration: invalid syntax: "abc"`+
+			`: {{hPath1}}:3 - "{{clkNano2}}|U|key1|abc"`,
+		`parseDuration: invalid syntax: "abc"`+
+			`: {{hPath1}}:3 - "{{clkNano2}}|U|key1|abc"`,
+	)
+}